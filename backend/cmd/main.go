@@ -1,8 +1,16 @@
 package main
 
 import (
+	"kyc-backend/config"
+	"kyc-backend/http/handlers/kycHandlers"
 	"kyc-backend/http/routes"
+	"kyc-backend/internal/blobstore"
 	"kyc-backend/internal/database"
+	"kyc-backend/internal/fieldcrypt"
+	"kyc-backend/internal/jointoken"
+	"kyc-backend/internal/notify"
+	"kyc-backend/internal/reminders"
+	"kyc-backend/internal/webhooks"
 	"log"
 	"os"
 
@@ -18,7 +26,15 @@ func main() {
 		log.Println("No .env file found, using system env")
 	}
 
+	config.Load()
+	fieldcrypt.Setup()
+	jointoken.Setup()
 	database.Connect()
+	webhooks.StartDispatcher()
+	notify.Setup()
+	notify.StartSender()
+	reminders.Start(kycHandlers.EscalateToGeneralPool)
+	blobstore.Setup()
 
 	router := gin.New()
 	router.Use(gin.Recovery())
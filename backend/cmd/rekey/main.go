@@ -0,0 +1,184 @@
+// Command rekey re-encrypts customer PII with the current encryption key and
+// encrypts rows written before field encryption existed. That covers
+// customers, queued notifications, the staff event log and document data
+// read during sessions. Run it after adding a new key version to
+// ENCRYPTION_KEYS; once it reports nothing left to do, the old key version
+// can be removed. It also fills in blind indexes that were added after a row
+// was written.
+package main
+
+import (
+	"database/sql"
+	"log"
+	"reflect"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/fieldcrypt"
+	"kyc-backend/internal/models"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+// storedCustomer is the raw, still-encrypted view of the PII columns
+type storedCustomer struct {
+	ID          uint
+	FullName    sql.NullString
+	Email       sql.NullString
+	Phone       sql.NullString
+	DateOfBirth sql.NullString
+	NationalID  sql.NullString
+
+	EmailIndex     sql.NullString
+	PhoneIndex     sql.NullString
+	BirthDateIndex sql.NullString
+	MergedIntoID   sql.NullInt64
+}
+
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system env")
+	}
+
+	config.Load()
+	fieldcrypt.Setup()
+	database.Connect()
+
+	var scanned, rekeyed int
+	var rows []storedCustomer
+
+	err := database.DB.Table("customers").
+		Select("id", "full_name", "email", "phone", "date_of_birth", "national_id",
+			"email_index", "phone_index", "birth_date_index", "merged_into_id").
+		FindInBatches(&rows, 200, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				scanned++
+				if !needsRekey(row) && !needsReindex(row) {
+					continue
+				}
+
+				var customer models.Customer
+				if err := database.DB.First(&customer, row.ID).Error; err != nil {
+					return err
+				}
+				if err := database.DB.Model(&customer).
+					Select("full_name", "email", "phone", "date_of_birth", "national_id",
+						"national_id_index", "email_index", "phone_index", "birth_date_index").
+					Updates(&customer).Error; err != nil {
+					return err
+				}
+				rekeyed++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		log.Fatalf("Rekey stopped after %d of %d customers: %v", rekeyed, scanned, err)
+	}
+
+	log.Printf("Rekey done: %d customers scanned, %d re-encrypted", scanned, rekeyed)
+
+	rekeyTable("outbox messages", &models.OutboxMessage{}, "recipient", "subject", "body", "attachments")
+	rekeyTable("admin events", &models.AdminEvent{}, "data")
+	rekeyTable("document extractions", &models.DocumentExtraction{}, "data", "checks")
+}
+
+// rekeyTable re-encrypts the given columns of every row of model's table
+func rekeyTable(name string, model any, columns ...string) {
+	var scanned, rekeyed int
+	var lastID uint
+
+	// Read the stored ciphertext as is, not through the model's field types
+	stmt := &gorm.Statement{DB: database.DB}
+	if err := stmt.Parse(model); err != nil {
+		log.Fatalf("Rekey of %s: %v", name, err)
+	}
+
+	for {
+		var rows []map[string]any
+		err := database.DB.Table(stmt.Schema.Table).
+			Select(append([]string{"id"}, columns...)).
+			Where("id > ?", lastID).Order("id").Limit(200).
+			Find(&rows).Error
+		if err != nil {
+			log.Fatalf("Rekey of %s stopped after %d of %d rows: %v", name, rekeyed, scanned, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			scanned++
+			lastID = toUint(row["id"])
+			if !rowNeedsRekey(row, columns) {
+				continue
+			}
+
+			record := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+			if err := database.DB.First(record, lastID).Error; err != nil {
+				log.Fatalf("Rekey of %s stopped at row %d: %v", name, lastID, err)
+			}
+			if err := database.DB.Model(record).Select(columns).Updates(record).Error; err != nil {
+				log.Fatalf("Rekey of %s stopped at row %d: %v", name, lastID, err)
+			}
+			rekeyed++
+		}
+	}
+
+	log.Printf("Rekey done: %d %s scanned, %d re-encrypted", scanned, name, rekeyed)
+}
+
+func rowNeedsRekey(row map[string]any, columns []string) bool {
+	for _, column := range columns {
+		var value string
+		switch v := row[column].(type) {
+		case string:
+			value = v
+		case []byte:
+			value = string(v)
+		}
+		if fieldcrypt.NeedsRekey(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func toUint(v any) uint {
+	switch id := v.(type) {
+	case uint:
+		return id
+	case int64:
+		return uint(id)
+	case uint64:
+		return uint(id)
+	case int:
+		return uint(id)
+	}
+	return 0
+}
+
+func needsRekey(row storedCustomer) bool {
+	for _, value := range []sql.NullString{row.FullName, row.Email, row.Phone, row.DateOfBirth, row.NationalID} {
+		if value.Valid && fieldcrypt.NeedsRekey(value.String) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsReindex spots rows written before the email, phone and birth date
+// indexes existed. Merged rows have no indexes on purpose.
+func needsReindex(row storedCustomer) bool {
+	if row.MergedIntoID.Valid {
+		return false
+	}
+	missing := func(value, index sql.NullString) bool {
+		return value.Valid && value.String != "" && index.String == ""
+	}
+	return missing(row.Email, row.EmailIndex) ||
+		missing(row.Phone, row.PhoneIndex) ||
+		missing(row.DateOfBirth, row.BirthDateIndex)
+}
@@ -3,11 +3,105 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var DB_FILE string
 var ENV string
 
+// AVG_SESSION_DURATION is used to estimate customer waiting times
+var AVG_SESSION_DURATION time.Duration
+
+// SKILL_FALLBACK_TIMEOUT is how long a request waits for a skilled agent
+// before it is offered to the general pool
+var SKILL_FALLBACK_TIMEOUT time.Duration
+
+// REMINDER_OFFSETS are the lead times before a meeting at which the customer
+// is reminded, e.g. REMINDER_OFFSETS=24h,15m
+var REMINDER_OFFSETS []time.Duration
+
+// Notification providers. Leaving SMTP_HOST or SMS_API_URL empty logs the
+// messages instead of sending them.
+var (
+	SMTP_HOST     string
+	SMTP_PORT     string
+	SMTP_USERNAME string
+	SMTP_PASSWORD string
+	SMTP_FROM     string
+
+	SMS_API_URL string
+	SMS_API_KEY string
+	SMS_FROM    string
+)
+
+// Upload storage. Leaving S3_ENDPOINT empty stores files under UPLOAD_DIR.
+var (
+	UPLOAD_DIR       string
+	UPLOAD_MAX_BYTES int64
+
+	S3_ENDPOINT   string
+	S3_REGION     string
+	S3_BUCKET     string
+	S3_ACCESS_KEY string
+	S3_SECRET_KEY string
+	S3_PATH_STYLE bool
+)
+
+// Customer meeting links. Join tokens stay valid for JOIN_LINK_GRACE after
+// the meeting time and allow JOIN_LINK_MAX_USES joins.
+var (
+	PUBLIC_BASE_URL    string
+	JOIN_TOKEN_SECRET  string
+	JOIN_LINK_GRACE    time.Duration
+	JOIN_LINK_MAX_USES int
+)
+
+// ALLOWED_ORIGINS are the browser origins allowed to call the API and open
+// the WebSocket, e.g. ALLOWED_ORIGINS=https://kyc.example.com. The default is
+// the Vite dev server and PUBLIC_BASE_URL.
+var ALLOWED_ORIGINS []string
+
+// Appointment slots. Meetings start on SLOT_LENGTH boundaries and can be
+// booked up to BOOKING_HORIZON ahead. DEFAULT_BRANCH is the branch code used
+// when a booking does not name one.
+var (
+	SLOT_LENGTH     time.Duration
+	BOOKING_HORIZON time.Duration
+	DEFAULT_BRANCH  string
+)
+
+// Profile validation. Phones without a country code are read in
+// DEFAULT_COUNTRY's numbering plan when the customer's nationality does not
+// say; customers must be at least MIN_CUSTOMER_AGE unless their country's
+// rules say otherwise.
+var (
+	DEFAULT_COUNTRY  string
+	MIN_CUSTOMER_AGE int
+)
+
+// Customer self-service limits: how often a customer may reschedule one
+// meeting, and how close to the meeting they may still do so
+var (
+	CUSTOMER_MAX_RESCHEDULES   int
+	CUSTOMER_RESCHEDULE_CUTOFF time.Duration
+)
+
+// Customer PII encryption. ENCRYPTION_KEYS lists "version:base64key" pairs;
+// new writes use ENCRYPTION_KEY_VERSION, or the last listed key. Like
+// JOIN_TOKEN_SECRET they are read by Load.
+var (
+	ENCRYPTION_KEYS        string
+	ENCRYPTION_KEY_VERSION string
+	BLIND_INDEX_KEY        string
+)
+
+// ALLOW_INSECURE_DEV_KEYS lets a development box run without ENCRYPTION_KEYS,
+// BLIND_INDEX_KEY or JOIN_TOKEN_SECRET by falling back to fixed keys that are
+// in the source. Without it missing keys are fatal.
+var ALLOW_INSECURE_DEV_KEYS bool
+
 func init() {
 	ENV = os.Getenv("ENVIRONMENT")
 	if(ENV == "") {
@@ -21,4 +115,118 @@ func init() {
 		DB_FILE = "goauth.db"
 	}
 
+	AVG_SESSION_DURATION = 10 * time.Minute
+	if minutes, err := strconv.Atoi(os.Getenv("AVG_SESSION_MINUTES")); err == nil && minutes > 0 {
+		AVG_SESSION_DURATION = time.Duration(minutes) * time.Minute
+	}
+
+	SKILL_FALLBACK_TIMEOUT = 2 * time.Minute
+	if seconds, err := strconv.Atoi(os.Getenv("SKILL_FALLBACK_SECONDS")); err == nil && seconds >= 0 {
+		SKILL_FALLBACK_TIMEOUT = time.Duration(seconds) * time.Second
+	}
+
+	REMINDER_OFFSETS = []time.Duration{24 * time.Hour, 15 * time.Minute}
+	if raw := os.Getenv("REMINDER_OFFSETS"); raw != "" {
+		REMINDER_OFFSETS = nil
+		for _, part := range strings.Split(raw, ",") {
+			offset, err := time.ParseDuration(strings.TrimSpace(part))
+			if err != nil || offset <= 0 {
+				log.Printf("Ignoring invalid reminder offset %q", part)
+				continue
+			}
+			REMINDER_OFFSETS = append(REMINDER_OFFSETS, offset)
+		}
+	}
+
+	SMTP_HOST = os.Getenv("SMTP_HOST")
+	SMTP_PORT = os.Getenv("SMTP_PORT")
+	if SMTP_PORT == "" {
+		SMTP_PORT = "587"
+	}
+	SMTP_USERNAME = os.Getenv("SMTP_USERNAME")
+	SMTP_PASSWORD = os.Getenv("SMTP_PASSWORD")
+	SMTP_FROM = os.Getenv("SMTP_FROM")
+
+	SMS_API_URL = os.Getenv("SMS_API_URL")
+	SMS_API_KEY = os.Getenv("SMS_API_KEY")
+	SMS_FROM = os.Getenv("SMS_FROM")
+
+	UPLOAD_DIR = os.Getenv("UPLOAD_DIR")
+	if UPLOAD_DIR == "" {
+		UPLOAD_DIR = "uploads"
+	}
+	UPLOAD_MAX_BYTES = 10 << 20
+	if mb, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_MB")); err == nil && mb > 0 {
+		UPLOAD_MAX_BYTES = int64(mb) << 20
+	}
+
+	S3_ENDPOINT = os.Getenv("S3_ENDPOINT")
+	S3_REGION = os.Getenv("S3_REGION")
+	if S3_REGION == "" {
+		S3_REGION = "us-east-1"
+	}
+	S3_BUCKET = os.Getenv("S3_BUCKET")
+	S3_ACCESS_KEY = os.Getenv("S3_ACCESS_KEY")
+	S3_SECRET_KEY = os.Getenv("S3_SECRET_KEY")
+	S3_PATH_STYLE, _ = strconv.ParseBool(os.Getenv("S3_PATH_STYLE"))
+
+	PUBLIC_BASE_URL = strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if PUBLIC_BASE_URL == "" {
+		log.Println("PUBLIC_BASE_URL not found, set to default")
+		PUBLIC_BASE_URL = "https://test-kyc-app.duckdns.org"
+	}
+	ALLOWED_ORIGINS = []string{"http://localhost:5173", PUBLIC_BASE_URL}
+	if raw := os.Getenv("ALLOWED_ORIGINS"); raw != "" {
+		ALLOWED_ORIGINS = nil
+		for _, part := range strings.Split(raw, ",") {
+			if origin := strings.TrimRight(strings.TrimSpace(part), "/"); origin != "" {
+				ALLOWED_ORIGINS = append(ALLOWED_ORIGINS, origin)
+			}
+		}
+	}
+
+	JOIN_LINK_GRACE = 2 * time.Hour
+	if grace, err := time.ParseDuration(os.Getenv("JOIN_LINK_GRACE")); err == nil && grace > 0 {
+		JOIN_LINK_GRACE = grace
+	}
+	JOIN_LINK_MAX_USES = 10
+	if uses, err := strconv.Atoi(os.Getenv("JOIN_LINK_MAX_USES")); err == nil && uses > 0 {
+		JOIN_LINK_MAX_USES = uses
+	}
+
+	SLOT_LENGTH = 15 * time.Minute
+	if minutes, err := strconv.Atoi(os.Getenv("SLOT_MINUTES")); err == nil && minutes > 0 && 24*60%minutes == 0 {
+		SLOT_LENGTH = time.Duration(minutes) * time.Minute
+	}
+	BOOKING_HORIZON = 30 * 24 * time.Hour
+	if days, err := strconv.Atoi(os.Getenv("BOOKING_HORIZON_DAYS")); err == nil && days > 0 {
+		BOOKING_HORIZON = time.Duration(days) * 24 * time.Hour
+	}
+	DEFAULT_BRANCH = os.Getenv("DEFAULT_BRANCH")
+
+	DEFAULT_COUNTRY = strings.ToUpper(os.Getenv("DEFAULT_COUNTRY"))
+	MIN_CUSTOMER_AGE = 18
+	if age, err := strconv.Atoi(os.Getenv("MIN_CUSTOMER_AGE")); err == nil && age >= 0 {
+		MIN_CUSTOMER_AGE = age
+	}
+
+	CUSTOMER_MAX_RESCHEDULES = 2
+	if n, err := strconv.Atoi(os.Getenv("CUSTOMER_MAX_RESCHEDULES")); err == nil && n >= 0 {
+		CUSTOMER_MAX_RESCHEDULES = n
+	}
+	CUSTOMER_RESCHEDULE_CUTOFF = 2 * time.Hour
+	if cutoff, err := time.ParseDuration(os.Getenv("CUSTOMER_RESCHEDULE_CUTOFF")); err == nil && cutoff >= 0 {
+		CUSTOMER_RESCHEDULE_CUTOFF = cutoff
+	}
+
+}
+
+// Load reads the secret keys. Missing keys are fatal, so unlike the settings
+// above they must be read after .env has been loaded into the environment.
+func Load() {
+	JOIN_TOKEN_SECRET = os.Getenv("JOIN_TOKEN_SECRET")
+	ENCRYPTION_KEYS = os.Getenv("ENCRYPTION_KEYS")
+	ENCRYPTION_KEY_VERSION = os.Getenv("ENCRYPTION_KEY_VERSION")
+	BLIND_INDEX_KEY = os.Getenv("BLIND_INDEX_KEY")
+	ALLOW_INSECURE_DEV_KEYS, _ = strconv.ParseBool(os.Getenv("ALLOW_INSECURE_DEV_KEYS"))
 }
\ No newline at end of file
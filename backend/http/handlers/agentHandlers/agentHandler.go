@@ -0,0 +1,264 @@
+package agentHandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kyc-backend/internal/clock"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/presence"
+	"kyc-backend/internal/routing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetAgentStatus(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	c.JSON(http.StatusOK, gin.H{"status": presence.Get(userID)})
+}
+
+func SetAgentStatus(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		Status string `json:"status" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if !presence.Valid(body.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status must be one of " + models.AgentAvailable + ", " + models.AgentBusy + ", " + models.AgentAway + ", " + models.AgentWrapUp,
+		})
+		return
+	}
+
+	if err := presence.Set(userID, body.Status); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Status updated", "status": body.Status})
+}
+
+func GetAgentSkills(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var user models.User
+	if err := database.DB.Select("id", "skills").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"skills": user.Skills})
+}
+
+// SetAgentSkills replaces an agent's skills. Skills are certifications, so
+// the route is admin-only.
+// SetAgentRole grants or takes away a user's staff role. An empty role
+// leaves the user without access to the staff routes.
+func SetAgentRole(c *gin.Context) {
+	agentID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		Role string `json:"role"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if body.Role != "" && body.Role != models.RoleAgent && body.Role != models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be agent, admin or empty"})
+		return
+	}
+
+	// An admin demoting themselves could leave nobody to undo it
+	if uint(agentID) == c.MustGet("user_id").(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	var agent models.User
+	if err := database.DB.First(&agent, uint(agentID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	agent.Role = body.Role
+	if err := database.DB.Model(&agent).Select("role").Updates(&agent).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "role": agent.Role})
+}
+
+func SetAgentSkills(c *gin.Context) {
+	agentID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		Skills []string `json:"skills"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	skills := make([]string, 0, len(body.Skills))
+	for _, s := range body.Skills {
+		if s = routing.Normalize(s); s != "" {
+			skills = append(skills, s)
+		}
+	}
+
+	var agent models.User
+	if err := database.DB.First(&agent, uint(agentID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	agent.Skills = skills
+	if err := database.DB.Model(&agent).Select("skills").Updates(&agent).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update skills"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Skills updated", "skills": skills})
+}
+
+func GetWorkingHours(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var hours []models.WorkingHours
+	if err := database.DB.Where("user_id = ?", userID).Order("weekday, start_minute").Find(&hours).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load working hours"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hours": toShifts(hours)})
+}
+
+// SetWorkingHours replaces an agent's weekly shifts, which decide how many
+// meetings can be booked into each slot. The route is admin-only.
+func SetWorkingHours(c *gin.Context) {
+	agentID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		Hours []clock.Shift `json:"hours"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	hours := make([]models.WorkingHours, 0, len(body.Hours))
+	for _, s := range body.Hours {
+		start, end, ok := s.Minutes()
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each shift needs a weekday 0-6 and a start before its end, as HH:MM"})
+			return
+		}
+		hours = append(hours, models.WorkingHours{
+			UserID:      uint(agentID),
+			Weekday:     s.Weekday,
+			StartMinute: start,
+			EndMinute:   end,
+		})
+	}
+
+	var agent models.User
+	if err := database.DB.Select("id").First(&agent, uint(agentID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", agent.ID).Delete(&models.WorkingHours{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update working hours"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Working hours updated", "hours": toShifts(hours)})
+}
+
+func toShifts(hours []models.WorkingHours) []clock.Shift {
+	shifts := make([]clock.Shift, 0, len(hours))
+	for _, h := range hours {
+		shifts = append(shifts, clock.Shift{
+			Weekday: h.Weekday,
+			Start:   clock.Format(h.StartMinute),
+			End:     clock.Format(h.EndMinute),
+		})
+	}
+	return shifts
+}
+
+// SetAgentBranch moves an agent to a branch, or out of all branches with a
+// null branch_id. The agent's working hours are then read in the branch's
+// time zone. The route is admin-only.
+func SetAgentBranch(c *gin.Context) {
+	agentID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		BranchID *uint `json:"branch_id"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if body.BranchID != nil {
+		var branch models.Branch
+		if err := database.DB.Select("id").First(&branch, *body.BranchID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Branch not found"})
+			return
+		}
+	}
+
+	var agent models.User
+	if err := database.DB.Select("id").First(&agent, uint(agentID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := database.DB.Model(&agent).Update("branch_id", body.BranchID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update branch"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Branch updated", "branch_id": body.BranchID})
+}
@@ -12,10 +12,13 @@ import (
 	"kyc-backend/internal/models"
 )
 
+// Register creates an account without a staff role. It cannot use the staff
+// routes until an admin grants it one with SetAgentRole.
 func Register(c *gin.Context) {
 	var body struct {
 		Email    string
 		Password string
+		Name     string
 	}
 
 	if err := c.BindJSON(&body); err != nil {
@@ -28,6 +31,7 @@ func Register(c *gin.Context) {
 	user := models.User{
 		Email:    body.Email,
 		Password: string(hash),
+		Name:     body.Name,
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
@@ -0,0 +1,256 @@
+package branchHandlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kyc-backend/internal/calendar"
+	"kyc-backend/internal/clock"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxCalendarBytes bounds an uploaded holiday calendar
+const maxCalendarBytes = 1 << 20
+
+func CreateBranch(c *gin.Context) {
+	var body struct {
+		Code     string `json:"code" binding:"required"`
+		Name     string `json:"name"`
+		Country  string `json:"country"`
+		TimeZone string `json:"time_zone" binding:"required"` // IANA
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if _, err := time.LoadLocation(body.TimeZone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time zone " + body.TimeZone})
+		return
+	}
+
+	branch := models.Branch{
+		Code:     models.NormalizeBranchCode(body.Code),
+		Name:     body.Name,
+		Country:  strings.ToUpper(body.Country),
+		TimeZone: body.TimeZone,
+	}
+	if branch.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	// The unique index on code settles concurrent creates
+	err := database.DB.Create(&branch).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A branch with that code already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create branch"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Branch created", "branch": branch})
+}
+
+func ListBranches(c *gin.Context) {
+	var branches []models.Branch
+	if err := database.DB.Order("code").Find(&branches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load branches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"branches": branches})
+}
+
+// SetBusinessHours replaces a branch's weekly opening hours, in its local
+// time. Slots outside them cannot be booked even when agents are working.
+func SetBusinessHours(c *gin.Context) {
+	branch, ok := loadBranch(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Hours []clock.Shift `json:"hours"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	hours := make([]models.BusinessHours, 0, len(body.Hours))
+	for _, s := range body.Hours {
+		start, end, ok := s.Minutes()
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each period needs a weekday 0-6 and a start before its end, as HH:MM"})
+			return
+		}
+		hours = append(hours, models.BusinessHours{
+			BranchID:    branch.ID,
+			Weekday:     s.Weekday,
+			StartMinute: start,
+			EndMinute:   end,
+		})
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("branch_id = ?", branch.ID).Delete(&models.BusinessHours{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update business hours"})
+		return
+	}
+
+	shifts := make([]clock.Shift, 0, len(hours))
+	for _, h := range hours {
+		shifts = append(shifts, clock.Shift{
+			Weekday: h.Weekday,
+			Start:   clock.Format(h.StartMinute),
+			End:     clock.Format(h.EndMinute),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Business hours updated", "hours": shifts})
+}
+
+// ImportHolidays adds the days of an .ics calendar, sent as the multipart
+// field "file" or as the raw body, to a branch's holidays. Days already on
+// the calendar are renamed rather than duplicated.
+func ImportHolidays(c *gin.Context) {
+	branch, ok := loadBranch(c)
+	if !ok {
+		return
+	}
+
+	var source io.Reader = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarBytes)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing calendar file"})
+			return
+		}
+		if file.Size > maxCalendarBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Calendar file is too large"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing calendar file"})
+			return
+		}
+		defer f.Close()
+		source = f
+	}
+
+	events, err := calendar.Parse(source)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Calendar file is too large"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar file"})
+		return
+	}
+
+	var holidays []models.Holiday
+	for _, e := range events {
+		for _, date := range e.Dates() {
+			holidays = append(holidays, models.Holiday{
+				BranchID: branch.ID,
+				Date:     date,
+				Name:     e.Summary,
+				Source:   e.UID,
+			})
+		}
+	}
+
+	if len(holidays) > 0 {
+		if err := database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "branch_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "source"}),
+		}).CreateInBatches(&holidays, 100).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import holidays"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holidays imported", "imported": len(holidays)})
+}
+
+// ListHolidays returns a branch's holidays, optionally limited to ?year=
+func ListHolidays(c *gin.Context) {
+	branch, ok := loadBranch(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("branch_id = ?", branch.ID)
+	if year := c.Query("year"); year != "" {
+		if _, err := strconv.Atoi(year); err != nil || len(year) != 4 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		query = query.Where("date LIKE ?", year+"-%")
+	}
+
+	var holidays []models.Holiday
+	if err := query.Order("date").Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load holidays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"holidays": holidays})
+}
+
+// DeleteHoliday reopens a branch on a day, given as 2006-01-02
+func DeleteHoliday(c *gin.Context) {
+	branch, ok := loadBranch(c)
+	if !ok {
+		return
+	}
+
+	result := database.DB.Where("branch_id = ? AND date = ?", branch.ID, c.Param("date")).Delete(&models.Holiday{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete holiday"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted"})
+}
+
+func loadBranch(c *gin.Context) (models.Branch, bool) {
+	var branch models.Branch
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch ID"})
+		return branch, false
+	}
+	if err := database.DB.First(&branch, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Branch not found"})
+		return branch, false
+	}
+	return branch, true
+}
@@ -0,0 +1,246 @@
+package customerHandlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/dedupe"
+	"kyc-backend/internal/kycstate"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/pii"
+	"kyc-backend/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// customerSummary is what a reviewer sees of each side of a duplicate pair;
+// the national ID stays masked
+type customerSummary struct {
+	ID         uint      `json:"id"`
+	FullName   string    `json:"full_name"`
+	NationalID string    `json:"national_id,omitempty"`
+	KYCStatus  string    `json:"kyc_status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ListDuplicates returns the duplicate review queue, pending entries unless
+// ?status= asks for merged or dismissed ones
+func ListDuplicates(c *gin.Context) {
+	status := c.DefaultQuery("status", models.DuplicatePending)
+	if status != models.DuplicatePending && status != models.DuplicateMerged && status != models.DuplicateDismissed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status " + status})
+		return
+	}
+
+	var candidates []models.DuplicateCandidate
+	if err := database.DB.Where("status = ?", status).
+		Order("score DESC, id").
+		Limit(200).
+		Find(&candidates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load duplicates"})
+		return
+	}
+
+	ids := make([]uint, 0, 2*len(candidates))
+	for _, d := range candidates {
+		ids = append(ids, d.CustomerID, d.DuplicateOfID)
+	}
+	var customers []models.Customer
+	if len(ids) > 0 {
+		if err := database.DB.Where("id IN ?", ids).Find(&customers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load duplicates"})
+			return
+		}
+	}
+	summaries := make(map[uint]customerSummary, len(customers))
+	for _, cu := range customers {
+		summaries[cu.ID] = customerSummary{
+			ID:         cu.ID,
+			FullName:   cu.FullName,
+			NationalID: pii.MaskNationalID(cu.NationalID),
+			KYCStatus:  cu.KYCStatus,
+			CreatedAt:  cu.CreatedAt,
+		}
+	}
+
+	entries := make([]gin.H, 0, len(candidates))
+	for _, d := range candidates {
+		entries = append(entries, gin.H{
+			"id":           d.ID,
+			"customer":     summaries[d.CustomerID],
+			"duplicate_of": summaries[d.DuplicateOfID],
+			"reasons":      d.Reasons,
+			"score":        d.Score,
+			"status":       d.Status,
+			"note":         d.Note,
+			"created_at":   d.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"duplicates": entries})
+}
+
+// DismissDuplicate marks a queued pair as different people
+func DismissDuplicate(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	candidate, ok := loadPendingDuplicate(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&candidate).Updates(map[string]any{
+		"status":      models.DuplicateDismissed,
+		"reviewed_by": userID,
+		"reviewed_at": now,
+		"note":        body.Note,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss duplicate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Duplicate dismissed"})
+}
+
+// MergeDuplicate merges a queued pair. The earlier record is kept unless the
+// body's keep names the newer one.
+func MergeDuplicate(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		Keep uint   `json:"keep"`
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	candidate, ok := loadPendingDuplicate(c)
+	if !ok {
+		return
+	}
+
+	sourceID, targetID := candidate.CustomerID, candidate.DuplicateOfID
+	switch body.Keep {
+	case 0, targetID:
+	case sourceID:
+		sourceID, targetID = targetID, sourceID
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keep must be one of the two customers"})
+		return
+	}
+
+	mergeCustomers(c, sourceID, targetID, userID, body.Note)
+}
+
+// MergeCustomers merges the customer in the path into the one named by the
+// body's into, for duplicates the queue did not catch
+func MergeCustomers(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return
+	}
+
+	var body struct {
+		Into   uint   `json:"into" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	mergeCustomers(c, uint(sourceID), body.Into, userID, body.Reason)
+}
+
+func mergeCustomers(c *gin.Context, sourceID, targetID, userID uint, reason string) {
+	var source, target models.Customer
+	var previousStatus string
+	var merge *models.CustomerMerge
+
+	// Both records are read inside the transaction that merges them
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+		previousStatus = target.KYCStatus
+
+		var err error
+		merge, err = dedupe.Merge(tx, &source, &target, userID, reason)
+		return err
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	case errors.Is(err, dedupe.ErrSameCustomer):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a customer into itself"})
+		return
+	case errors.Is(err, dedupe.ErrAlreadyMerged):
+		c.JSON(http.StatusConflict, gin.H{"error": "One of the customers has already been merged"})
+		return
+	case errors.Is(err, dedupe.ErrBothOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": "Both customers have an open meeting; cancel one first"})
+		return
+	case errors.Is(err, dedupe.ErrTargetStatus), errors.Is(err, kycstate.ErrIllegalTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": "The kept customer cannot take over an open meeting in status " + previousStatus})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge customers"})
+		return
+	}
+
+	webhooks.Emit(webhooks.EventCustomerMerged, gin.H{
+		"customer_id":    target.ID,
+		"merged_id":      source.ID,
+		"sessions_moved": merge.Sessions,
+	})
+	if previousStatus != target.KYCStatus {
+		webhooks.Emit(webhooks.EventStatusChanged, gin.H{
+			"customer_id": target.ID,
+			"from":        previousStatus,
+			"to":          target.KYCStatus,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Customers merged", "merge": merge})
+}
+
+func loadPendingDuplicate(c *gin.Context) (models.DuplicateCandidate, bool) {
+	var candidate models.DuplicateCandidate
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duplicate ID"})
+		return candidate, false
+	}
+	if err := database.DB.First(&candidate, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Duplicate not found"})
+		return candidate, false
+	}
+	if candidate.Status != models.DuplicatePending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Duplicate has already been " + candidate.Status})
+		return candidate, false
+	}
+	return candidate, true
+}
@@ -0,0 +1,171 @@
+package kycHandlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/blobstore"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/documents"
+	"kyc-backend/internal/jointoken"
+	"kyc-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadDocument stores the ID front, ID back or selfie sent as the "file"
+// field of a multipart form. The customer authenticates with the meeting's
+// access token.
+func UploadDocument(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	kind := c.Param("kind")
+
+	if !documents.Valid(kind) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown document kind"})
+		return
+	}
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if _, err := jointoken.Verify(c.Query("token"), session); err != nil {
+		status, msg := jointoken.Reject(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	if session.Status != models.SessionScheduled && session.Status != models.SessionOngoing {
+		c.JSON(http.StatusConflict, gin.H{"error": "Documents can no longer be uploaded for this meeting"})
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.UPLOAD_MAX_BYTES+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, config.UPLOAD_MAX_BYTES+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	contentType, ext, err := documents.Inspect(data, config.UPLOAD_MAX_BYTES)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	suffix, err := newAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	customer := session.Customer
+	key := fmt.Sprintf("customers/%d/%s-%s%s", customer.ID, kind, suffix[:16], ext)
+
+	if err := blobstore.Store.Put(c.Request.Context(), key, contentType, data); err != nil {
+		log.Printf("Failed to store %s for customer %d: %v", kind, customer.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	previous := *documents.Field(&customer, kind)
+	if err := database.DB.Model(&models.Customer{}).
+		Where("id = ?", customer.ID).
+		Update(documents.Column(kind), key).Error; err != nil {
+		blobstore.Store.Delete(c.Request.Context(), key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
+		return
+	}
+
+	// The new upload replaces the old one; a leftover object is harmless
+	if previous != "" {
+		if err := blobstore.Store.Delete(c.Request.Context(), previous); err != nil {
+			log.Printf("Failed to delete replaced %s %s: %v", kind, previous, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Document uploaded",
+		"kind":         kind,
+		"content_type": contentType,
+		"size":         len(data),
+	})
+}
+
+// GetDocument streams one of the customer's uploads to the agent assigned
+// to the meeting, or to an admin
+func GetDocument(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	kind := c.Param("kind")
+	userID := c.MustGet("user_id").(uint)
+
+	if !documents.Valid(kind) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown document kind"})
+		return
+	}
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	key := *documents.Field(&session.Customer, kind)
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not uploaded"})
+		return
+	}
+
+	body, err := blobstore.Store.Get(c.Request.Context(), key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load document"})
+		return
+	}
+	defer body.Close()
+
+	c.Header("Cache-Control", "no-store")
+	c.DataFromReader(http.StatusOK, -1, mime.TypeByExtension(path.Ext(key)), body, nil)
+}
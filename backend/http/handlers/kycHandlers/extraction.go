@@ -0,0 +1,230 @@
+package kycHandlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"kyc-backend/internal/aamva"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/docmatch"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/mrz"
+	"kyc-backend/internal/pii"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maskedDataFields are the document data fields that carry the customer's
+// national ID, by source. Responses mask them; RevealPII returns them whole.
+var maskedDataFields = map[string][]string{
+	models.ExtractionMRZ:   {"optional_data", "optional_data2"},
+	models.ExtractionAAMVA: {"licence_number"},
+}
+
+// licenceNumberElement repeats the licence number in the AAMVA subfiles
+const licenceNumberElement = "DAQ"
+
+// VerifyMRZ reads the machine readable zone the agent captured from the
+// customer's passport or ID card, checks its check digits and compares it
+// with the profile. The result is kept on the session.
+func VerifyMRZ(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		MRZ string `json:"mrz" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	session, ok := loadSession(c)
+	if !ok {
+		return
+	}
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	doc, err := mrz.Parse(body.MRZ, time.Now())
+	var checkErr *mrz.CheckError
+	if errors.As(err, &checkErr) {
+		// Usually a misread character; the agent should capture it again
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  "MRZ check digits do not match",
+			"fields": checkErr.Fields,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read the MRZ"})
+		return
+	}
+
+	extracted := docmatch.Extracted{
+		Surname:        doc.Surname,
+		GivenNames:     doc.GivenNames,
+		BirthDate:      &doc.BirthDate,
+		Nationality:    doc.Nationality,
+		DocumentNumber: doc.DocumentNumber,
+		PersonalNumber: doc.OptionalData,
+		ExpiryDate:     &doc.ExpiryDate,
+	}
+	saveExtraction(c, session, userID, models.ExtractionMRZ, doc.Format, doc, extracted)
+}
+
+// VerifyLicence reads the decoded PDF417 barcode from the back of a US or
+// Canadian driver's licence or ID card and compares it with the profile.
+// Licences do not state nationality, so that check stays unverified.
+func VerifyLicence(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		Payload string `json:"payload" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	session, ok := loadSession(c)
+	if !ok {
+		return
+	}
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	licence, err := aamva.Parse(body.Payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read the licence barcode"})
+		return
+	}
+
+	extracted := docmatch.Extracted{
+		Surname:        licence.FamilyName,
+		GivenNames:     strings.TrimSpace(licence.FirstName + " " + licence.MiddleNames),
+		BirthDate:      licence.BirthDate,
+		DocumentNumber: licence.LicenceNumber,
+		ExpiryDate:     licence.ExpiryDate,
+	}
+	saveExtraction(c, session, userID, models.ExtractionAAMVA, licence.DocumentType, licence, extracted)
+}
+
+// ListExtractions returns the document data read during a session, with
+// national IDs masked
+func ListExtractions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	session, ok := loadSession(c)
+	if !ok {
+		return
+	}
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	var extractions []models.DocumentExtraction
+	if err := database.DB.Where("session_id = ?", session.ID).Order("id").Find(&extractions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load document data"})
+		return
+	}
+
+	for i := range extractions {
+		extractions[i].Data = maskData(extractions[i].Source, extractions[i].Data)
+	}
+	c.JSON(http.StatusOK, gin.H{"extractions": extractions})
+}
+
+// saveExtraction compares extracted document data with the profile, stores
+// both and responds with the result
+func saveExtraction(c *gin.Context, session models.KYCSession, userID uint, source, document string, data any, extracted docmatch.Extracted) {
+	checks := docmatch.Compare(extracted, session.Customer, time.Now())
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document data"})
+		return
+	}
+
+	extraction := models.DocumentExtraction{
+		SessionID:  session.ID,
+		CustomerID: session.CustomerID,
+		AgentID:    userID,
+		Source:     source,
+		Document:   document,
+		Data:       raw,
+		Checks:     checks,
+		Mismatches: docmatch.Mismatches(checks),
+	}
+	if err := database.DB.Create(&extraction).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document data"})
+		return
+	}
+
+	extraction.Data = maskData(source, raw)
+	c.JSON(http.StatusOK, gin.H{"extraction": extraction})
+}
+
+// dataFields decodes stored document data into its JSON fields
+func dataFields(data json.RawMessage) (map[string]any, error) {
+	var fields map[string]any
+	err := json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// maskData masks the national ID fields of document data for display.
+// Data that cannot be decoded is withheld rather than shown unmasked.
+func maskData(source string, data json.RawMessage) json.RawMessage {
+	fields, err := dataFields(data)
+	if err != nil {
+		return nil
+	}
+
+	for _, name := range maskedDataFields[source] {
+		if value, ok := fields[name].(string); ok {
+			fields[name] = pii.MaskNationalID(value)
+		}
+	}
+	if subfiles, ok := fields["subfiles"].(map[string]any); ok {
+		for _, subfile := range subfiles {
+			if elements, ok := subfile.(map[string]any); ok {
+				if value, ok := elements[licenceNumberElement].(string); ok {
+					elements[licenceNumberElement] = pii.MaskNationalID(value)
+				}
+			}
+		}
+	}
+
+	masked, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	return masked
+}
+
+// extractionValue returns one masked field of a session's document data in
+// full, and false when the extraction or the field cannot be revealed
+func extractionValue(session models.KYCSession, extractionID uint, field string) (string, bool) {
+	var extraction models.DocumentExtraction
+	if err := database.DB.Where("id = ? AND session_id = ?", extractionID, session.ID).First(&extraction).Error; err != nil {
+		return "", false
+	}
+	if !slices.Contains(maskedDataFields[extraction.Source], field) {
+		return "", false
+	}
+
+	fields, err := dataFields(extraction.Data)
+	if err != nil {
+		return "", false
+	}
+	value, _ := fields[field].(string)
+	return value, true
+}
@@ -1,24 +1,50 @@
 package kycHandlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"kyc-backend/config"
 	"kyc-backend/http/handlers/sseHandlers"
+	"kyc-backend/http/handlers/wsHandlers"
+	"kyc-backend/internal/calendar"
 	"kyc-backend/internal/database"
+	"kyc-backend/internal/dedupe"
+	"kyc-backend/internal/jointoken"
+	"kyc-backend/internal/kycstate"
+	"kyc-backend/internal/links"
 	"kyc-backend/internal/models"
+	"kyc-backend/internal/notify"
+	"kyc-backend/internal/presence"
+	"kyc-backend/internal/reminders"
+	"kyc-backend/internal/routing"
+	"kyc-backend/internal/slots"
+	"kyc-backend/internal/validation"
+	"kyc-backend/internal/waitingroom"
+	"kyc-backend/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func SubmitKYCProfile(c *gin.Context) {
 	var body struct {
-		FullName    string `json:"fullName" binding:"required"`
-		Email       string `json:"email" binding:"required,email"`
+		FullName    string `json:"fullName"`
+		Email       string `json:"email"`
 		Phone       string `json:"phone"`
 		DateOfBirth string `json:"dateOfBirth"`
 		NationalID  string `json:"nationalID"`
+
+		PreferredLanguage string `json:"preferredLanguage"`
+		Nationality       string `json:"nationality"`
+		Product           string `json:"product"`
+		TimeZone          string `json:"timeZone"` // IANA, e.g. "Asia/Kathmandu"
 	}
 
 
@@ -34,30 +60,115 @@ func SubmitKYCProfile(c *gin.Context) {
 	// 	return
 	// }
 
-	var dob *time.Time
+	profile := validation.Profile{
+		FullName:    body.FullName,
+		Email:       body.Email,
+		Phone:       body.Phone,
+		NationalID:  body.NationalID,
+		Nationality: body.Nationality,
+	}
+
+	fieldErrors := validation.Errors{}
 	if body.DateOfBirth != "" {
 		parsed, err := time.Parse("2006-01-02", body.DateOfBirth)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
-			return
+			fieldErrors.Add(validation.FieldDateOfBirth, validation.CodeInvalidFormat)
+		} else {
+			profile.DateOfBirth = &parsed
 		}
-		dob = &parsed
+	}
+	for field, code := range profile.Validate(time.Now()) {
+		fieldErrors.Add(field, code)
+	}
+	if len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some details are invalid", "fields": fieldErrors})
+		return
+	}
+
+	if _, ok := parseTimeZone(c, body.TimeZone); !ok {
+		return
 	}
 
 	customer := models.Customer{
-		FullName:       body.FullName,
-		Email:          body.Email,
-		Phone:          body.Phone,
-		DateOfBirth:    dob,
-		NationalID:     body.NationalID,
-		KYCStatus:      "profile_submitted",
+		FullName:       profile.FullName,
+		Email:          profile.Email,
+		Phone:          profile.Phone,
+		DateOfBirth:    profile.DateOfBirth,
+		NationalID:     profile.NationalID,
+		KYCStatus:      models.KYCProfileSubmitted,
+
+		PreferredLanguage: body.PreferredLanguage,
+		Nationality:       profile.Nationality,
+		Product:           body.Product,
+		TimeZone:          body.TimeZone,
+	}
+
+	// A certain duplicate is refused and the existing customer is told on
+	// their own contact details; weaker matches are saved and queued for
+	// review. The refusal names neither the record nor the matching fields,
+	// since anyone can call this endpoint.
+	matches, err := dedupe.Find(database.DB, customer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save profile"})
+		return
+	}
+	if len(matches) > 0 && matches[0].Certain {
+		// At most one notice a day, so the form cannot be used to flood
+		// the customer
+		reference := fmt.Sprintf("duplicate:%d", matches[0].Customer.ID)
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var recent int64
+			if err := tx.Model(&models.OutboxMessage{}).
+				Where("reference = ? AND created_at > ?", reference, time.Now().Add(-24*time.Hour)).
+				Count(&recent).Error; err != nil || recent > 0 {
+				return err
+			}
+			for _, msg := range duplicateAttemptMessages(matches[0].Customer) {
+				if err := notify.Enqueue(tx, msg, reference); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save profile"})
+			return
+		}
+		notify.Wake()
+
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "We could not start a new verification with these details. If you have applied before, check your email or phone for a message from us.",
+		})
+		return
 	}
 
-	if err := database.DB.Create(&customer).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&customer).Error; err != nil {
+			return err
+		}
+		if err := kycstate.RecordCustomerCreated(tx, customer, kycstate.SystemActor, "profile submitted"); err != nil {
+			return err
+		}
+		return dedupe.Flag(tx, customer, matches)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save profile"})
 		return
 	}
 
+	webhooks.Emit(webhooks.EventProfileSubmitted, gin.H{
+		"customer_id": customer.ID,
+		"kyc_status":  customer.KYCStatus,
+	})
+	for _, m := range matches {
+		webhooks.Emit(webhooks.EventDuplicateSuspected, gin.H{
+			"customer_id":     customer.ID,
+			"duplicate_of_id": m.Customer.ID,
+			"reasons":         m.Reasons,
+			"score":           m.Score,
+		})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "KYC profile submitted",
 		"customer_id": customer.ID,
@@ -70,6 +181,7 @@ func ScheduleKYCMeeting(c *gin.Context) {
 	var body struct {
 		CustomerID  uint   `json:"customer_id" binding:"required"`
 		ScheduledAt string `json:"scheduled_at" binding:"required"` // RFC3339
+		Branch      string `json:"branch"`                             // branch code, else DEFAULT_BRANCH
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -77,47 +189,133 @@ func ScheduleKYCMeeting(c *gin.Context) {
 		return
 	}
 
-	scheduledTime, err := time.Parse(time.RFC3339, body.ScheduledAt)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datetime format"})
+	branch, ok := resolveBranch(c, body.Branch)
+	if !ok {
+		return
+	}
+
+	scheduledTime, ok := parseSlotTime(c, branchKey(branchID(branch)), body.ScheduledAt)
+	if !ok {
 		return
 	}
 
 	// Verify customer exists
 	var customer models.Customer
-	if err := database.DB.Select("id").Where("id = ?", body.CustomerID).First(&customer).Error; err != nil {
+	if err := database.DB.Where("id = ?", body.CustomerID).First(&customer).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
 		return
 	}
+	if customer.MergedIntoID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This profile was merged into another"})
+		return
+	}
 
-	meetingID := "kyc_" + fmt.Sprintf("%d", time.Now().UnixNano())
+	meetingID, err := newMeetingID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule"})
+		return
+	}
+
+	accessToken, err := newAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule"})
+		return
+	}
 
 	session := models.KYCSession{
 		CustomerID:  customer.ID,
 		MeetingID:   meetingID,
 		ScheduledAt: scheduledTime,
-		Status:      "scheduled",
+		Status:      models.SessionScheduled,
+		AccessToken: accessToken,
+		BranchID:    branchID(branch),
+		HoldsSlot:   true,
 	}
 
-	if err := database.DB.Create(&session).Error; err != nil {
+	joinToken := jointoken.Issue(session)
+	link := links.MeetingLink(meetingID, joinToken)
+	previousStatus := customer.KYCStatus
+
+	// The session, the customer status and the outgoing notifications are
+	// committed together so a booked meeting always sends its link
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := slots.Reserve(tx, branchKey(session.BranchID), scheduledTime); err != nil {
+			return err
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		if err := kycstate.RecordSessionCreated(tx, session, kycstate.SystemActor, "meeting booked"); err != nil {
+			return err
+		}
+
+		// Update customer status
+		if err := kycstate.TransitionCustomer(tx, &customer, models.KYCScheduled, kycstate.SystemActor, "meeting booked"); err != nil {
+			return err
+		}
+
+		for _, msg := range meetingScheduledMessages(session, customer, link) {
+			if err := notify.Enqueue(tx, msg, meetingID); err != nil {
+				return err
+			}
+		}
+		return reminders.Schedule(tx, session)
+	})
+	if errors.Is(err, kycstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Customer cannot be scheduled in status " + previousStatus})
+		return
+	}
+	if respondSlotError(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule"})
 		return
 	}
+	notify.Wake()
 
-	// Update customer status
-	database.DB.Model(&customer).Update("kyc_status", "scheduled")
-
-	// In real app: send email/SMS with link
-
-	meetingLink := fmt.Sprintf("https://test-kyc-app.duckdns.org//kyc/%s", meetingID)
+	webhooks.Emit(webhooks.EventMeetingScheduled, gin.H{
+		"meeting_id":   meetingID,
+		"customer_id":  customer.ID,
+		"scheduled_at": scheduledTime,
+	})
+	if previousStatus != customer.KYCStatus {
+		webhooks.Emit(webhooks.EventStatusChanged, gin.H{
+			"customer_id": customer.ID,
+			"from":        previousStatus,
+			"to":          customer.KYCStatus,
+		})
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Meeting scheduled",
-		"meeting_link": meetingLink,
+		"meeting_link": link,
 		"meeting_id":   meetingID,
+		"join_token":   joinToken,
 	})
 }
 
+// newMeetingID returns an unguessable meeting ID
+func newMeetingID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "kyc_" + hex.EncodeToString(buf), nil
+}
+
+// newAccessToken returns a random nonce; join tokens are signed over it
+func newAccessToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GetKYCMeeting is the customer's view of their meeting, limited to what the
+// join page needs. The customer authenticates with the meeting's access
+// token; staff use GetSessionDetails instead.
 func GetKYCMeeting(c *gin.Context) {
 	meetingID := c.Param("meetingId")
 
@@ -130,26 +328,63 @@ func GetKYCMeeting(c *gin.Context) {
 		return
 	}
 
-	if session.Status != "scheduled" {
+	if _, err := jointoken.Verify(c.Query("token"), session); err != nil {
+		status, msg := jointoken.Reject(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	if session.Status != models.SessionScheduled {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting not available"})
 		return
 	}
 
+	firstName, _, _ := strings.Cut(strings.TrimSpace(session.Customer.FullName), " ")
+
 	c.JSON(http.StatusOK, gin.H{
 		"meeting_id":   session.MeetingID,
-		"national_id":  session.Customer.NationalID, // ← expose for verification
 		"scheduled_at": session.ScheduledAt,
-		"customer": gin.H{
-			"name":  session.Customer.FullName,
-			"email": session.Customer.Email,
-		},
-		"status": session.Status,
+		"status":       session.Status,
+		"first_name":   firstName,
+		"documents":    uploadedDocuments(session.Customer),
 	})
 }
 
+// DownloadMeetingInvite serves the meeting as an .ics file. The customer
+// authenticates with the meeting's access token.
+func DownloadMeetingInvite(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if _, err := jointoken.Verify(c.Query("token"), session); err != nil {
+		status, msg := jointoken.Reject(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	method := calendar.MethodRequest
+	if session.Status == models.SessionCancelled {
+		method = calendar.MethodCancel
+	}
+
+	invite := meetingInvite(session, session.Customer, links.MeetingLink(meetingID, jointoken.Issue(session)), method)
+
+	c.Header("Content-Disposition", `attachment; filename="kyc-meeting.ics"`)
+	c.Data(http.StatusOK, invite.ContentType(), invite.Render())
+}
+
 func NotifyAdmin(c *gin.Context) {
 	var body struct {
 		MeetingID string `json:"meeting_id"`
+		Token     string `json:"token"`
 	}
 
 	if err := c.BindJSON(&body); err != nil {
@@ -157,7 +392,6 @@ func NotifyAdmin(c *gin.Context) {
 		return
 	}
 
-	// Fetch customer's national ID
 	var session models.KYCSession
 	if err := database.DB.
 		Where("meeting_id = ?", body.MeetingID).
@@ -167,14 +401,232 @@ func NotifyAdmin(c *gin.Context) {
 		return
 	}
 
-	// Notify all connected admins
-	sseHandlers.NotifyAdmins(body.MeetingID, session.Customer.NationalID)
+	// Each arrival in the waiting room counts against the link's use limit
+	claims, err := jointoken.Verify(body.Token, session)
+	if err == nil {
+		err = jointoken.Consume(database.DB, &session, claims)
+	}
+	if err != nil {
+		status, msg := jointoken.Reject(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	required := routing.RequiredSkills(session.Customer)
+	escalated := false
+
+	// Put the customer in the waiting room unless an agent already has them
+	if session.Status == models.SessionScheduled && session.AgentID == nil {
+		entry := waitingroom.Entry{
+			MeetingID:   session.MeetingID,
+			Queue:       session.Queue,
+			ScheduledAt: session.ScheduledAt,
+			Skills:      required,
+		}
+		if session.Escalated {
+			entry.Skills = nil
+			entry.Escalated = true
+		}
+		escalated = waitingroom.Join(entry).Escalated
+		wsHandlers.PushQueuePositions()
+
+		// Offer the customer to everyone if no skilled agent picks them up.
+		// The deadline is kept from the first arrival and the reminders
+		// poller acts on it, so it survives refreshes and restarts.
+		if len(required) > 0 && !escalated {
+			if err := database.DB.Model(&models.KYCSession{}).
+				Where("id = ? AND escalate_at IS NULL", session.ID).
+				Update("escalate_at", time.Now().Add(config.SKILL_FALLBACK_TIMEOUT)).Error; err != nil {
+				log.Printf("Failed to set escalation deadline for %s: %v", session.MeetingID, err)
+			}
+		}
+	}
+
+	// Notify matching agents
+	sseHandlers.NotifyAdmins(session, required, escalated)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Admin notified"})
 }
 
+// EscalateToGeneralPool re-announces a still-waiting meeting to all
+// available agents once the skills-based routing window has passed. The
+// reminders scheduler calls it after marking the session escalated.
+func EscalateToGeneralPool(session models.KYCSession) {
+	if !waitingroom.Escalate(session.MeetingID) {
+		return
+	}
+	wsHandlers.PushQueuePositions()
+	sseHandlers.NotifyAdmins(session, routing.RequiredSkills(session.Customer), true)
+}
+
+func ClaimKYCSession(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
+
+	session, claimed, err := claimSession(meetingID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+	if errors.Is(err, errSkillsMismatch) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This customer needs an agent with other skills"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim meeting"})
+		return
+	}
+
+	if !claimed {
+		// Claiming twice is fine, anything else lost the race
+		if session.AgentID != nil && *session.AgentID == userID {
+			c.JSON(http.StatusOK, gin.H{"message": "Meeting already claimed by you"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting already claimed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meeting claimed"})
+}
+
+func ListWaitingQueue(c *gin.Context) {
+	waiting := waitingroom.Waiting(c.Query("queue"))
+
+	list := make([]gin.H, 0, len(waiting))
+	for i, e := range waiting {
+		list = append(list, gin.H{
+			"position":     i + 1,
+			"meeting_id":   e.MeetingID,
+			"queue":        e.Queue,
+			"scheduled_at": e.ScheduledAt,
+			"arrived_at":   e.ArrivedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"waiting": list})
+}
+
+// NextInQueue claims the customer at the head of the waiting room for the
+// calling agent.
+func NextInQueue(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	if !presence.IsAvailable(userID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Set your status to available to take customers"})
+		return
+	}
+
+	var agent models.User
+	if err := database.DB.Select("id", "skills").First(&agent, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	for _, e := range waitingroom.Waiting(c.Query("queue")) {
+		// Skip customers reserved for agents with other skills
+		if !routing.Matches(agent.Skills, e.Skills) {
+			continue
+		}
+
+		session, claimed, err := claimSession(e.MeetingID, userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Session vanished; drop the stale entry and keep looking
+			waitingroom.Leave(e.MeetingID)
+			continue
+		}
+		if errors.Is(err, errSkillsMismatch) {
+			continue
+		}
+		if err != nil {
+			// The customer is still waiting; a database hiccup must not
+			// take them out of the queue
+			log.Printf("Failed to claim meeting %s: %v", e.MeetingID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim meeting"})
+			return
+		}
+		if !claimed {
+			// A meeting that was cancelled or finished cannot be claimed by
+			// anyone; one held by another agent stays for them
+			if session.Status != models.SessionScheduled {
+				waitingroom.Leave(e.MeetingID)
+			}
+			continue
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Meeting claimed",
+			"meeting_id":   session.MeetingID,
+			"scheduled_at": session.ScheduledAt,
+		})
+		return
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "No customers waiting"})
+}
+
+// errSkillsMismatch is returned by claimSession when the agent lacks a skill
+// the customer needs and the meeting has not been escalated
+var errSkillsMismatch = errors.New("agent lacks the required skills")
+
+// claimSession assigns the meeting to the agent if nobody has it yet. The
+// update is conditional on agent_id being empty, so only the first claimer
+// wins. Until the meeting is escalated only agents with the customer's
+// skills, or admins, may claim it. On success the meeting leaves the waiting
+// room and staff are told.
+func claimSession(meetingID string, userID uint) (models.KYCSession, bool, error) {
+	var session models.KYCSession
+
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		return session, false, err
+	}
+	if session.AgentID == nil && !session.Escalated {
+		var agent models.User
+		if err := database.DB.Select("id", "role", "skills").First(&agent, userID).Error; err != nil {
+			return session, false, fmt.Errorf("load agent %d: %v", userID, err)
+		}
+		if agent.Role != models.RoleAdmin && !routing.Matches(agent.Skills, routing.RequiredSkills(session.Customer)) {
+			return session, false, errSkillsMismatch
+		}
+	}
+
+	result := database.DB.Model(&models.KYCSession{}).
+		Where("meeting_id = ? AND agent_id IS NULL AND status = ?", meetingID, models.SessionScheduled).
+		Update("agent_id", userID)
+	if result.Error != nil {
+		return session, false, result.Error
+	}
+
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		First(&session).Error; err != nil {
+		return session, false, err
+	}
+
+	if result.RowsAffected == 0 {
+		return session, false, nil
+	}
+
+	if waitingroom.Leave(meetingID) {
+		wsHandlers.PushQueuePositions()
+	}
+
+	sseHandlers.NotifyCustomer(meetingID)
+
+	sseHandlers.Publish(session, sseHandlers.SessionClaimed{
+		MeetingID: session.MeetingID,
+		AgentID:   userID,
+	})
+
+	return session, true, nil
+}
+
 func StartKYCSession(c *gin.Context) {
 	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
 
 	var session models.KYCSession
 	if err := database.DB.
@@ -184,13 +636,223 @@ func StartKYCSession(c *gin.Context) {
 		return
 	}
 
-	// Optional: verify user is agent (via JWT)
-	// userID := c.MustGet("user_id").(uint)
-	// TODO: check if user is agent
+	// Only the agent who claimed the meeting may start it
+	if session.AgentID == nil || *session.AgentID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
 
 	// Update status
-	session.Status = "ongoing"
-	database.DB.Save(&session)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return kycstate.TransitionSession(tx, &session, models.SessionOngoing, kycstate.AgentActor(userID), "call started")
+	})
+	if errors.Is(err, kycstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting cannot be started from status " + session.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start meeting"})
+		return
+	}
+
+	sseHandlers.Publish(session, sseHandlers.SessionStarted{
+		MeetingID: session.MeetingID,
+		AgentID:   userID,
+	})
+	sseHandlers.NotifyCustomer(session.MeetingID)
+
+	webhooks.Emit(webhooks.EventSessionStarted, gin.H{
+		"meeting_id":  session.MeetingID,
+		"customer_id": session.CustomerID,
+		"agent_id":    userID,
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Meeting started"})
-}
\ No newline at end of file
+}
+
+// MarkNoShow records that the customer never turned up. Only the assigned
+// agent or an admin may do this, so unclaimed meetings are for admins.
+func MarkNoShow(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	actor := kycstate.AgentActor(userID)
+	customer := session.Customer
+	previousStatus := customer.KYCStatus
+
+	// The customer goes back to profile_submitted so they can book again
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kycstate.TransitionSession(tx, &session, models.SessionNoShow, actor, "customer did not join"); err != nil {
+			return err
+		}
+		return kycstate.TransitionCustomer(tx, &customer, models.KYCProfileSubmitted, actor, "missed meeting "+session.MeetingID)
+	})
+	if errors.Is(err, kycstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting cannot be marked no-show from status " + session.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meeting"})
+		return
+	}
+
+	if waitingroom.Leave(session.MeetingID) {
+		wsHandlers.PushQueuePositions()
+	}
+
+	sseHandlers.Publish(session, sseHandlers.NoShow{
+		MeetingID:   session.MeetingID,
+		ScheduledAt: session.ScheduledAt,
+	})
+	sseHandlers.NotifyCustomer(session.MeetingID)
+
+	if previousStatus != customer.KYCStatus {
+		webhooks.Emit(webhooks.EventStatusChanged, gin.H{
+			"customer_id": customer.ID,
+			"from":        previousStatus,
+			"to":          customer.KYCStatus,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meeting marked as no-show"})
+}
+
+// FailKYCSession ends a call that broke off without a verdict, such as a
+// dropped connection the customer never came back from. The customer can
+// book again. Only the assigned agent or an admin may do it.
+func FailKYCSession(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return
+	}
+	reason := strings.TrimSpace(body.Reason)
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	actor := kycstate.AgentActor(userID)
+	customer := session.Customer
+	previousStatus := customer.KYCStatus
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kycstate.TransitionSession(tx, &session, models.SessionFailed, actor, reason); err != nil {
+			return err
+		}
+		if customer.KYCStatus != models.KYCScheduled {
+			return nil
+		}
+		return kycstate.TransitionCustomer(tx, &customer, models.KYCProfileSubmitted, actor, "failed meeting "+session.MeetingID)
+	})
+	if errors.Is(err, kycstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting cannot be failed from status " + session.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meeting"})
+		return
+	}
+
+	wsHandlers.EndRoom(session.MeetingID, models.SessionFailed)
+
+	var agentID uint
+	if session.AgentID != nil {
+		agentID = *session.AgentID
+		if err := presence.Set(agentID, models.AgentAvailable); err != nil {
+			log.Printf("Failed to mark agent %d available: %v", agentID, err)
+		}
+	}
+	sseHandlers.Publish(session, sseHandlers.SessionFailed{
+		MeetingID: session.MeetingID,
+		AgentID:   agentID,
+		Reason:    reason,
+	})
+	sseHandlers.NotifyCustomer(session.MeetingID)
+
+	webhooks.Emit(webhooks.EventSessionFailed, gin.H{
+		"meeting_id":  session.MeetingID,
+		"customer_id": customer.ID,
+		"agent_id":    agentID,
+		"reason":      reason,
+	})
+	if previousStatus != customer.KYCStatus {
+		webhooks.Emit(webhooks.EventStatusChanged, gin.H{
+			"customer_id": customer.ID,
+			"from":        previousStatus,
+			"to":          customer.KYCStatus,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meeting marked as failed"})
+}
+
+// GetSessionHistory lists the meeting's status changes and moves. Like the
+// meeting details, only the assigned agent or an admin may see it.
+func GetSessionHistory(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	history, err := kycstate.SessionHistory(database.DB, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load history"})
+		return
+	}
+
+	reschedules, err := kycstate.RescheduleHistory(database.DB, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"meeting_id":   session.MeetingID,
+		"status":       session.Status,
+		"scheduled_at": session.ScheduledAt,
+		"history":      history,
+		"reschedules":  reschedules,
+	})
+}
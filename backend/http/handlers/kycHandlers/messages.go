@@ -0,0 +1,147 @@
+package kycHandlers
+
+import (
+	"fmt"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/calendar"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/notify"
+)
+
+// meetingInvite describes the session as a calendar event. The meeting ID is
+// the UID so every update replaces the same calendar entry.
+func meetingInvite(session models.KYCSession, customer models.Customer, meetingLink, method string) calendar.Invite {
+	agentName := ""
+	if session.AgentID != nil {
+		var agent models.User
+		if err := database.DB.Select("id", "name", "email").First(&agent, *session.AgentID).Error; err == nil {
+			agentName = agent.Name
+			if agentName == "" {
+				agentName = agent.Email
+			}
+		}
+	}
+
+	return calendar.Invite{
+		UID:            session.MeetingID + "@kyc-backend",
+		Sequence:       session.InviteSequence,
+		Method:         method,
+		Start:          session.ScheduledAt,
+		Duration:       config.AVG_SESSION_DURATION,
+		Summary:        "KYC video verification",
+		Link:           meetingLink,
+		AgentName:      agentName,
+		OrganizerEmail: config.SMTP_FROM,
+		AttendeeName:   customer.FullName,
+		AttendeeEmail:  customer.Email,
+	}
+}
+
+func inviteAttachment(invite calendar.Invite) models.OutboxAttachment {
+	return models.OutboxAttachment{
+		Filename:    "kyc-meeting.ics",
+		ContentType: invite.ContentType(),
+		Data:        invite.Render(),
+	}
+}
+
+// meetingScheduledMessages builds the email and SMS sent to a customer once
+// their verification call is booked
+func meetingScheduledMessages(session models.KYCSession, customer models.Customer, meetingLink string) []notify.Message {
+	when := session.ScheduledAt.In(customer.Location()).Format("Mon, 02 Jan 2006 15:04 MST")
+	invite := meetingInvite(session, customer, meetingLink, calendar.MethodRequest)
+
+	return []notify.Message{
+		{
+			Channel: notify.ChannelEmail,
+			To:      customer.Email,
+			Subject: "Your KYC video verification is scheduled",
+			Body: fmt.Sprintf(
+				"Hello %s,\n\nYour identity verification call is scheduled for %s.\n\nJoin using this link:\n%s\n\nPlease have your identity document ready.\n",
+				customer.FullName, when, meetingLink,
+			),
+			Attachments: []models.OutboxAttachment{inviteAttachment(invite)},
+		},
+		{
+			Channel: notify.ChannelSMS,
+			To:      customer.Phone,
+			Body:    fmt.Sprintf("Your KYC video call is on %s. Join: %s", when, meetingLink),
+		},
+	}
+}
+
+// meetingRescheduledMessages tells the customer about the new time. The
+// invite carries a higher sequence so calendars move the existing entry.
+func meetingRescheduledMessages(session models.KYCSession, customer models.Customer, meetingLink string, previous time.Time) []notify.Message {
+	when := session.ScheduledAt.In(customer.Location()).Format("Mon, 02 Jan 2006 15:04 MST")
+	was := previous.In(customer.Location()).Format("Mon, 02 Jan 2006 15:04 MST")
+	invite := meetingInvite(session, customer, meetingLink, calendar.MethodRequest)
+
+	return []notify.Message{
+		{
+			Channel: notify.ChannelEmail,
+			To:      customer.Email,
+			Subject: "Your KYC video verification has been rescheduled",
+			Body: fmt.Sprintf(
+				"Hello %s,\n\nYour identity verification call has moved from %s to %s.\n\nJoin using this new link, the previous one no longer works:\n%s\n",
+				customer.FullName, was, when, meetingLink,
+			),
+			Attachments: []models.OutboxAttachment{inviteAttachment(invite)},
+		},
+		{
+			Channel: notify.ChannelSMS,
+			To:      customer.Phone,
+			Body:    fmt.Sprintf("Your KYC video call moved to %s. New link: %s", when, meetingLink),
+		},
+	}
+}
+
+// meetingCancelledMessages tells the customer the call is off and removes it
+// from their calendar
+func meetingCancelledMessages(session models.KYCSession, customer models.Customer) []notify.Message {
+	when := session.ScheduledAt.In(customer.Location()).Format("Mon, 02 Jan 2006 15:04 MST")
+	invite := meetingInvite(session, customer, "", calendar.MethodCancel)
+
+	return []notify.Message{
+		{
+			Channel: notify.ChannelEmail,
+			To:      customer.Email,
+			Subject: "Your KYC video verification has been cancelled",
+			Body: fmt.Sprintf(
+				"Hello %s,\n\nYour identity verification call on %s has been cancelled.\n\nYou can book a new time whenever you are ready.\n",
+				customer.FullName, when,
+			),
+			Attachments: []models.OutboxAttachment{inviteAttachment(invite)},
+		},
+		{
+			Channel: notify.ChannelSMS,
+			To:      customer.Phone,
+			Body:    fmt.Sprintf("Your KYC video call on %s has been cancelled.", when),
+		},
+	}
+}
+
+// duplicateAttemptMessages tells an existing customer that someone tried to
+// start a new verification with their details. It goes to the contact
+// details on file, not to whoever submitted the form.
+func duplicateAttemptMessages(customer models.Customer) []notify.Message {
+	return []notify.Message{
+		{
+			Channel: notify.ChannelEmail,
+			To:      customer.Email,
+			Subject: "Someone tried to start a KYC verification with your details",
+			Body: fmt.Sprintf(
+				"Hello %s,\n\nA new identity verification was just requested with details that match your existing profile, so it was not started.\n\nIf this was you, there is no need to apply again: use the meeting link we sent you, or contact us to book a new time. If it was not you, please let us know.\n",
+				customer.FullName,
+			),
+		},
+		{
+			Channel: notify.ChannelSMS,
+			To:      customer.Phone,
+			Body:    "A new KYC verification was requested with your details and was not started. If this was not you, please contact us.",
+		},
+	}
+}
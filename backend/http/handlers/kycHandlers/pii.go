@@ -0,0 +1,155 @@
+package kycHandlers
+
+import (
+	"net/http"
+	"strings"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/documents"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/pii"
+
+	"github.com/gin-gonic/gin"
+)
+
+// revealableFields are the customer fields staff may unmask
+var revealableFields = map[string]func(models.Customer) string{
+	"national_id": func(c models.Customer) string { return c.NationalID },
+}
+
+// GetSessionDetails is the staff view of a meeting. Only the assigned agent or
+// an admin may open it, and the national ID is masked; use RevealPII to see
+// the full value.
+func GetSessionDetails(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	customer := session.Customer
+	c.JSON(http.StatusOK, gin.H{
+		"meeting_id":   session.MeetingID,
+		"scheduled_at": session.ScheduledAt,
+		"status":       session.Status,
+		"queue":        session.Queue,
+		"agent_id":     session.AgentID,
+		"customer": gin.H{
+			"id":                 customer.ID,
+			"name":               customer.FullName,
+			"email":              customer.Email,
+			"phone":              customer.Phone,
+			"date_of_birth":      customer.DateOfBirth,
+			"national_id":        pii.MaskNationalID(customer.NationalID),
+			"nationality":        customer.Nationality,
+			"preferred_language": customer.PreferredLanguage,
+			"product":            customer.Product,
+			"kyc_status":         customer.KYCStatus,
+		},
+		"documents": uploadedDocuments(customer),
+	})
+}
+
+// RevealPII returns the unmasked value of one customer field, or with an
+// extraction_id, of one masked field of document data read in the session.
+// Every reveal is recorded with the reason given.
+func RevealPII(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		Field        string `json:"field" binding:"required"`
+		Reason       string `json:"reason" binding:"required"`
+		ExtractionID *uint  `json:"extraction_id"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A field and a reason are required"})
+		return
+	}
+
+	valueOf, ok := revealableFields[body.Field]
+	if !ok && body.ExtractionID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Field cannot be revealed"})
+		return
+	}
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	var value string
+	if body.ExtractionID != nil {
+		if value, ok = extractionValue(session, *body.ExtractionID, body.Field); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Field cannot be revealed"})
+			return
+		}
+	} else {
+		value = valueOf(session.Customer)
+	}
+
+	// No audit record, no reveal
+	reveal := models.PIIReveal{
+		UserID:       userID,
+		CustomerID:   session.CustomerID,
+		MeetingID:    session.MeetingID,
+		ExtractionID: body.ExtractionID,
+		Field:        body.Field,
+		Reason:       strings.TrimSpace(body.Reason),
+		IPAddress:    c.ClientIP(),
+	}
+	if err := database.DB.Create(&reveal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record reveal"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"field": body.Field,
+		"value": value,
+	})
+}
+
+// canViewSession allows the agent assigned to the meeting and admins
+func canViewSession(session models.KYCSession, userID uint) bool {
+	if session.AgentID != nil && *session.AgentID == userID {
+		return true
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.Role == models.RoleAdmin
+}
+
+// uploadedDocuments reports which uploads the customer has made, without
+// exposing where they are stored
+func uploadedDocuments(customer models.Customer) gin.H {
+	uploaded := gin.H{}
+	for _, kind := range []string{documents.KindIDFront, documents.KindIDBack, documents.KindSelfie} {
+		uploaded[kind] = *documents.Field(&customer, kind) != ""
+	}
+	return uploaded
+}
@@ -0,0 +1,328 @@
+package kycHandlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/http/handlers/sseHandlers"
+	"kyc-backend/http/handlers/wsHandlers"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/jointoken"
+	"kyc-backend/internal/kycstate"
+	"kyc-backend/internal/links"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/notify"
+	"kyc-backend/internal/slots"
+	"kyc-backend/internal/waitingroom"
+	"kyc-backend/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type rescheduleBody struct {
+	ScheduledAt string `json:"scheduled_at" binding:"required"` // RFC3339
+	Reason      string `json:"reason"`
+}
+
+type cancelBody struct {
+	Reason string `json:"reason"`
+}
+
+// RescheduleKYCSession lets the assigned agent or an admin move a scheduled
+// meeting to another time
+func RescheduleKYCSession(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var body rescheduleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	session, ok := loadSession(c)
+	if !ok {
+		return
+	}
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	// A meeting stays with its branch when it moves
+	scheduledAt, ok := parseSlotTime(c, branchKey(session.BranchID), body.ScheduledAt)
+	if !ok {
+		return
+	}
+
+	rescheduleMeeting(c, session, scheduledAt, kycstate.AgentActor(userID), body.Reason)
+}
+
+// CancelKYCSession lets the assigned agent or an admin call off a scheduled
+// meeting
+func CancelKYCSession(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	// The reason is optional, so an empty body is fine
+	var body cancelBody
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	session, ok := loadSession(c)
+	if !ok {
+		return
+	}
+	if !canViewSession(session, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	cancelMeeting(c, session, kycstate.AgentActor(userID), body.Reason)
+}
+
+// CustomerRescheduleMeeting lets the customer move their own meeting using the
+// link's join token. Customers may only reschedule a limited number of times
+// and not too close to the start.
+func CustomerRescheduleMeeting(c *gin.Context) {
+	var body rescheduleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	session, ok := loadCustomerSession(c)
+	if !ok {
+		return
+	}
+
+	scheduledAt, ok := parseSlotTime(c, branchKey(session.BranchID), body.ScheduledAt)
+	if !ok {
+		return
+	}
+
+	if session.CustomerReschedules >= config.CUSTOMER_MAX_RESCHEDULES {
+		c.JSON(http.StatusConflict, gin.H{"error": "This meeting cannot be rescheduled again"})
+		return
+	}
+	if time.Until(session.ScheduledAt) < config.CUSTOMER_RESCHEDULE_CUTOFF {
+		c.JSON(http.StatusConflict, gin.H{"error": "It is too late to reschedule this meeting"})
+		return
+	}
+	if time.Until(scheduledAt) < config.CUSTOMER_RESCHEDULE_CUTOFF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The new time is too soon"})
+		return
+	}
+
+	rescheduleMeeting(c, session, scheduledAt, kycstate.CustomerActor(session.CustomerID), body.Reason)
+}
+
+// CustomerCancelMeeting lets the customer call off their own meeting using
+// the link's join token
+func CustomerCancelMeeting(c *gin.Context) {
+	// The reason is optional, so an empty body is fine
+	var body cancelBody
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	session, ok := loadCustomerSession(c)
+	if !ok {
+		return
+	}
+
+	cancelMeeting(c, session, kycstate.CustomerActor(session.CustomerID), body.Reason)
+}
+
+func rescheduleMeeting(c *gin.Context, session models.KYCSession, scheduledAt time.Time, actor kycstate.Actor, reason string) {
+	nonce, err := newAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule"})
+		return
+	}
+
+	// Keep the pre-move copy so the agent who had claimed it hears about it
+	previous := session
+	customer := session.Customer
+	if reason == "" {
+		reason = "rescheduled by " + actor.Type
+	}
+
+	var link string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if previous.HoldsSlot {
+			if err := slots.Release(tx, branchKey(session.BranchID), previous.ScheduledAt); err != nil {
+				return err
+			}
+		}
+		if err := slots.Reserve(tx, branchKey(session.BranchID), scheduledAt); err != nil {
+			return err
+		}
+		if err := kycstate.Reschedule(tx, &session, scheduledAt, nonce, actor, reason); err != nil {
+			return err
+		}
+		if !session.HoldsSlot {
+			if err := tx.Model(&session).Update("holds_slot", true).Error; err != nil {
+				return err
+			}
+		}
+
+		link = links.MeetingLink(session.MeetingID, jointoken.Issue(session))
+		for _, msg := range meetingRescheduledMessages(session, customer, link, previous.ScheduledAt) {
+			if err := notify.Enqueue(tx, msg, session.MeetingID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, kycstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting cannot be rescheduled from status " + previous.Status})
+		return
+	}
+	if respondSlotError(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule"})
+		return
+	}
+	notify.Wake()
+
+	if waitingroom.Leave(session.MeetingID) {
+		wsHandlers.PushQueuePositions()
+	}
+
+	sseHandlers.Publish(previous, sseHandlers.SessionRescheduled{
+		MeetingID:           session.MeetingID,
+		ScheduledAt:         session.ScheduledAt,
+		PreviousScheduledAt: previous.ScheduledAt,
+		By:                  actor.Type,
+	})
+	sseHandlers.NotifyCustomer(session.MeetingID)
+
+	webhooks.Emit(webhooks.EventMeetingRescheduled, gin.H{
+		"meeting_id":            session.MeetingID,
+		"customer_id":           customer.ID,
+		"scheduled_at":          session.ScheduledAt,
+		"previous_scheduled_at": previous.ScheduledAt,
+		"by":                    actor.Type,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Meeting rescheduled",
+		"meeting_id":   session.MeetingID,
+		"scheduled_at": session.ScheduledAt,
+		"meeting_link": link,
+	})
+}
+
+func cancelMeeting(c *gin.Context, session models.KYCSession, actor kycstate.Actor, reason string) {
+	customer := session.Customer
+	previousStatus := customer.KYCStatus
+	if reason == "" {
+		reason = "cancelled by " + actor.Type
+	}
+
+	// The customer goes back to profile_submitted so they can book again
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kycstate.TransitionSession(tx, &session, models.SessionCancelled, actor, reason); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.KYCSession{}).
+			Where("id = ?", session.ID).
+			Update("invite_sequence", gorm.Expr("invite_sequence + 1")).Error; err != nil {
+			return err
+		}
+		session.InviteSequence++
+
+		// Only give back a place the meeting actually counted in
+		if session.HoldsSlot {
+			if err := slots.Release(tx, branchKey(session.BranchID), session.ScheduledAt); err != nil {
+				return err
+			}
+			if err := tx.Model(&session).Update("holds_slot", false).Error; err != nil {
+				return err
+			}
+		}
+
+		if customer.KYCStatus == models.KYCScheduled {
+			if err := kycstate.TransitionCustomer(tx, &customer, models.KYCProfileSubmitted, actor, "cancelled meeting "+session.MeetingID); err != nil {
+				return err
+			}
+		}
+
+		for _, msg := range meetingCancelledMessages(session, customer) {
+			if err := notify.Enqueue(tx, msg, session.MeetingID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, kycstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting cannot be cancelled from status " + session.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel"})
+		return
+	}
+	notify.Wake()
+
+	if waitingroom.Leave(session.MeetingID) {
+		wsHandlers.PushQueuePositions()
+	}
+
+	sseHandlers.Publish(session, sseHandlers.SessionCancelled{
+		MeetingID:   session.MeetingID,
+		ScheduledAt: session.ScheduledAt,
+		By:          actor.Type,
+	})
+	sseHandlers.NotifyCustomer(session.MeetingID)
+
+	webhooks.Emit(webhooks.EventMeetingCancelled, gin.H{
+		"meeting_id":  session.MeetingID,
+		"customer_id": customer.ID,
+		"by":          actor.Type,
+	})
+	if previousStatus != customer.KYCStatus {
+		webhooks.Emit(webhooks.EventStatusChanged, gin.H{
+			"customer_id": customer.ID,
+			"from":        previousStatus,
+			"to":          customer.KYCStatus,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meeting cancelled"})
+}
+
+func loadSession(c *gin.Context) (models.KYCSession, bool) {
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", c.Param("meetingId")).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return session, false
+	}
+	return session, true
+}
+
+// loadCustomerSession is loadSession for customers, checking the join token
+func loadCustomerSession(c *gin.Context) (models.KYCSession, bool) {
+	session, ok := loadSession(c)
+	if !ok {
+		return session, false
+	}
+
+	if _, err := jointoken.Verify(c.Query("token"), session); err != nil {
+		status, msg := jointoken.Reject(err)
+		c.JSON(status, gin.H{"error": msg})
+		return session, false
+	}
+	return session, true
+}
@@ -0,0 +1,177 @@
+package kycHandlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/slots"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxSlotWindow = 31 * 24 * time.Hour
+
+// ListSlots returns a branch's bookable slots between ?from= and ?to=,
+// defaulting to the coming week. ?branch= picks the branch by code and ?tz=
+// is the customer's IANA time zone: slots are returned in that zone, and
+// from and to may be plain dates (2006-01-02) there.
+func ListSlots(c *gin.Context) {
+	loc, ok := parseTimeZone(c, c.Query("tz"))
+	if !ok {
+		return
+	}
+
+	branch, ok := resolveBranch(c, c.Query("branch"))
+	if !ok {
+		return
+	}
+
+	from := time.Now()
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := parseSlotBound(raw, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from datetime"})
+			return
+		}
+		from = parsed
+	}
+
+	to := from.Add(7 * 24 * time.Hour)
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := parseSlotBound(raw, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to datetime"})
+			return
+		}
+		to = parsed
+	}
+
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+	if to.Sub(from) > maxSlotWindow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ask for at most 31 days of slots at a time"})
+		return
+	}
+
+	list, err := slots.List(branchKey(branchID(branch)), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load slots"})
+		return
+	}
+	for i := range list {
+		list[i].Start = list[i].Start.In(loc)
+		list[i].End = list[i].End.In(loc)
+	}
+
+	response := gin.H{"slots": list, "time_zone": loc.String()}
+	if branch != nil {
+		response["branch"] = gin.H{"code": branch.Code, "name": branch.Name, "time_zone": branch.TimeZone}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseSlotBound reads an RFC3339 time, or a date taken as midnight in loc
+func parseSlotBound(raw string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", raw, loc)
+}
+
+// parseTimeZone reads an IANA time zone name, defaulting to UTC
+func parseTimeZone(c *gin.Context, name string) (*time.Location, bool) {
+	if name == "" {
+		return time.UTC, true
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time zone " + name})
+		return nil, false
+	}
+	return loc, true
+}
+
+// resolveBranch looks up a branch by code, in any case, falling back to
+// DEFAULT_BRANCH. It returns nil when neither names a branch.
+func resolveBranch(c *gin.Context, code string) (*models.Branch, bool) {
+	code = models.NormalizeBranchCode(code)
+	if code == "" {
+		code = models.NormalizeBranchCode(config.DEFAULT_BRANCH)
+	}
+	if code == "" {
+		return nil, true
+	}
+
+	var branch models.Branch
+	if err := database.DB.Where("code = ?", code).First(&branch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Branch not found"})
+		return nil, false
+	}
+	return &branch, true
+}
+
+// branchID is the branch to record on a session, nil for no branch
+func branchID(branch *models.Branch) *uint {
+	if branch == nil {
+		return nil
+	}
+	return &branch.ID
+}
+
+// branchKey is the slots package's branch ID, 0 for no branch
+func branchKey(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// parseSlotTime reads a requested meeting time and checks it is the start of
+// a bookable slot of the branch. Capacity is checked when the slot is reserved.
+func parseSlotTime(c *gin.Context, branchID uint, value string) (time.Time, bool) {
+	scheduledAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datetime format"})
+		return scheduledAt, false
+	}
+
+	scheduledAt, err = slots.Validate(branchID, scheduledAt)
+	if err != nil {
+		respondSlotError(c, err)
+		return scheduledAt, false
+	}
+	return scheduledAt, true
+}
+
+// respondSlotError reports a slot validation or reservation failure and
+// returns false for any other error, which the caller handles
+func respondSlotError(c *gin.Context, err error) bool {
+	var holiday *slots.HolidayError
+	switch {
+	case errors.Is(err, slots.ErrPast):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Meeting time must be in the future"})
+	case errors.Is(err, slots.ErrBeyondHorizon):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Meeting time is too far ahead"})
+	case errors.Is(err, slots.ErrNotSlotStart):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Meeting time must be the start of a slot"})
+	case errors.As(err, &holiday):
+		c.JSON(http.StatusConflict, gin.H{"error": holiday.Date + " is a public holiday (" + holiday.Name + ")"})
+	case errors.Is(err, slots.ErrOutsideHours):
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting time is outside the branch's business hours"})
+	case errors.Is(err, slots.ErrNoBranch):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Branch not found"})
+	case errors.Is(err, slots.ErrClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "No agents are available at that time"})
+	case errors.Is(err, slots.ErrFull):
+		c.JSON(http.StatusConflict, gin.H{"error": "That slot is fully booked"})
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package kycHandlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"kyc-backend/http/handlers/sseHandlers"
+	"kyc-backend/http/handlers/wsHandlers"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/kycstate"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/presence"
+	"kyc-backend/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// decisionStatus maps a verdict decision to the customer's new KYC status
+var decisionStatus = map[string]string{
+	models.DecisionApprove:       models.KYCVerified,
+	models.DecisionReject:        models.KYCRejected,
+	models.DecisionNeedsMoreInfo: models.KYCMoreInfoNeeded,
+}
+
+// CompleteKYCSession records the assigned agent's decision, ends the call and
+// moves both the session and the customer to their final status.
+func CompleteKYCSession(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+	userID := c.MustGet("user_id").(uint)
+
+	var body struct {
+		Decision    string   `json:"decision" binding:"required"`
+		ReasonCodes []string `json:"reason_codes"`
+		Notes       string   `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	kycStatus, ok := decisionStatus[body.Decision]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Decision must be approve, reject or needs_more_info"})
+		return
+	}
+	if msg := validateReasons(body.Decision, body.ReasonCodes, body.Notes); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if session.AgentID == nil || *session.AgentID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Meeting is not assigned to you"})
+		return
+	}
+
+	actor := kycstate.AgentActor(userID)
+	customer := session.Customer
+	previousStatus := customer.KYCStatus
+	reason := body.Decision
+	if len(body.ReasonCodes) > 0 {
+		reason += ": " + strings.Join(body.ReasonCodes, ", ")
+	}
+
+	verdict := models.KYCVerdict{
+		SessionID:   session.ID,
+		CustomerID:  customer.ID,
+		AgentID:     userID,
+		Decision:    body.Decision,
+		ReasonCodes: body.ReasonCodes,
+		Notes:       body.Notes,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kycstate.TransitionSession(tx, &session, models.SessionCompleted, actor, reason); err != nil {
+			return err
+		}
+		if err := kycstate.TransitionCustomer(tx, &customer, kycStatus, actor, reason); err != nil {
+			return err
+		}
+		return tx.Create(&verdict).Error
+	})
+	if errors.Is(err, kycstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meeting cannot be completed from status " + session.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save verdict"})
+		return
+	}
+
+	wsHandlers.EndRoom(session.MeetingID, body.Decision)
+
+	if err := presence.Set(userID, models.AgentAvailable); err != nil {
+		log.Printf("Failed to mark agent %d available: %v", userID, err)
+	}
+
+	sseHandlers.Publish(session, sseHandlers.SessionCompleted{
+		MeetingID: session.MeetingID,
+		AgentID:   userID,
+		Outcome:   body.Decision,
+	})
+	sseHandlers.NotifyCustomer(session.MeetingID)
+
+	webhooks.Emit(webhooks.EventSessionCompleted, gin.H{
+		"meeting_id":   session.MeetingID,
+		"customer_id":  customer.ID,
+		"agent_id":     userID,
+		"decision":     body.Decision,
+		"reason_codes": body.ReasonCodes,
+	})
+	if previousStatus != customer.KYCStatus {
+		webhooks.Emit(webhooks.EventStatusChanged, gin.H{
+			"customer_id": customer.ID,
+			"from":        previousStatus,
+			"to":          customer.KYCStatus,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Verification completed",
+		"verdict":    verdict,
+		"kyc_status": customer.KYCStatus,
+	})
+}
+
+// validateReasons checks the reason codes against the decision and returns a
+// user-facing error, or "" when they are fine
+func validateReasons(decision string, codes []string, notes string) string {
+	allowed := models.ReasonCodes[decision]
+
+	if decision != models.DecisionApprove && len(codes) == 0 {
+		return "At least one reason code is required"
+	}
+
+	for _, code := range codes {
+		found := false
+		for _, a := range allowed {
+			if code == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "Reason code " + code + " is not valid for " + decision
+		}
+		if code == "other" && strings.TrimSpace(notes) == "" {
+			return "Notes are required when the reason is other"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package sseHandlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/jointoken"
+	"kyc-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stages shown to the customer while their KYC progresses
+const (
+	StageScheduled      = models.SessionScheduled
+	StageAgentJoining   = "agent_joining"
+	StageOngoing        = models.SessionOngoing
+	StageVerified       = models.KYCVerified
+	StageRejected       = models.KYCRejected
+	StageMoreInfoNeeded = models.KYCMoreInfoNeeded
+)
+
+// CustomerStatus is the only event sent on the customer stream
+type CustomerStatus struct {
+	MeetingID     string `json:"meeting_id"`
+	Stage         string `json:"stage"`
+	SessionStatus string `json:"session_status"`
+	KYCStatus     string `json:"kyc_status"`
+}
+
+// Global map: meetingID -> customer streams watching it
+var (
+	customerStreams = make(map[string]map[chan CustomerStatus]bool)
+	customerMutex   = &sync.RWMutex{}
+)
+
+// customerStage maps the session and customer state to a customer-facing stage
+func customerStage(session models.KYCSession) string {
+	switch session.Customer.KYCStatus {
+	case StageVerified, StageRejected, StageMoreInfoNeeded:
+		return session.Customer.KYCStatus
+	}
+	if session.Status == models.SessionOngoing {
+		return StageOngoing
+	}
+	if session.Status == models.SessionScheduled && session.AgentID != nil {
+		return StageAgentJoining
+	}
+	return session.Status
+}
+
+func statusOf(session models.KYCSession) CustomerStatus {
+	return CustomerStatus{
+		MeetingID:     session.MeetingID,
+		Stage:         customerStage(session),
+		SessionStatus: session.Status,
+		KYCStatus:     session.Customer.KYCStatus,
+	}
+}
+
+// CustomerStatusHandler streams status changes of one meeting to its
+// customer. The meeting's access token is passed as ?token= because
+// EventSource cannot set headers.
+func CustomerStatusHandler(c *gin.Context) {
+	meetingID := c.Param("meetingId")
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meeting not found"})
+		return
+	}
+
+	if _, err := jointoken.Verify(c.Query("token"), session); err != nil {
+		status, msg := jointoken.Reject(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan CustomerStatus, 10)
+	customerMutex.Lock()
+	if customerStreams[meetingID] == nil {
+		customerStreams[meetingID] = make(map[chan CustomerStatus]bool)
+	}
+	customerStreams[meetingID][ch] = true
+	customerMutex.Unlock()
+
+	defer func() {
+		customerMutex.Lock()
+		delete(customerStreams[meetingID], ch)
+		if len(customerStreams[meetingID]) == 0 {
+			delete(customerStreams, meetingID)
+		}
+		close(ch)
+		customerMutex.Unlock()
+	}()
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	// Start with where things stand right now
+	writeCustomerStatus(c, statusOf(session))
+	flusher.Flush()
+
+	for {
+		select {
+		case status := <-ch:
+			writeCustomerStatus(c, status)
+			flusher.Flush()
+		case <-ticker.C:
+			c.SSEvent("ping", "keep-alive")
+			flusher.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+func writeCustomerStatus(c *gin.Context, status CustomerStatus) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		log.Printf("Failed to encode customer status: %v", err)
+		return
+	}
+	c.SSEvent("status", string(data))
+}
+
+// NotifyCustomer pushes the meeting's current status to its customer streams
+func NotifyCustomer(meetingID string) {
+	customerMutex.RLock()
+	watching := len(customerStreams[meetingID]) > 0
+	customerMutex.RUnlock()
+	if !watching {
+		return
+	}
+
+	var session models.KYCSession
+	if err := database.DB.
+		Where("meeting_id = ?", meetingID).
+		Preload("Customer").
+		First(&session).Error; err != nil {
+		log.Printf("Failed to load meeting %s for customer status: %v", meetingID, err)
+		return
+	}
+	status := statusOf(session)
+
+	customerMutex.RLock()
+	defer customerMutex.RUnlock()
+
+	for ch := range customerStreams[meetingID] {
+		select {
+		case ch <- status:
+		default:
+			// Skip if channel is full; the next update carries the full state
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package sseHandlers
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"kyc-backend/internal/models"
+)
+
+// Event types sent to staff over the SSE stream
+const (
+	EventMeetingRequest   = "meeting_request"
+	EventCustomerJoined   = "customer_joined"
+	EventCustomerLeft     = "customer_left"
+	EventSessionStarted   = "session_started"
+	EventSessionClaimed   = "session_claimed"
+	EventSessionCompleted = "session_completed"
+	EventSessionFailed    = "session_failed"
+	EventNoShow           = "no_show"
+	EventRescheduled      = "session_rescheduled"
+	EventCancelled        = "session_cancelled"
+
+	// EventReset tells a reconnecting client that events it missed are no
+	// longer in the log, so it must refetch its state
+	EventReset = "reset"
+)
+
+// Payload is the body of a typed staff event
+type Payload interface {
+	EventType() string
+}
+
+// MeetingRequest is sent when a customer asks for an agent to join
+type MeetingRequest struct {
+	MeetingID    string    `json:"meeting_id"`
+	NationalID   string    `json:"national_id"` // masked
+	CustomerName string    `json:"customer_name"`
+	ScheduledAt  time.Time `json:"scheduled_at"`
+
+	RequiredSkills []string `json:"required_skills,omitempty"`
+	Fallback       bool     `json:"fallback,omitempty"` // no skilled agent took it in time
+}
+
+// CustomerJoined is sent when the customer connects to the meeting room
+type CustomerJoined struct {
+	MeetingID string `json:"meeting_id"`
+	ClientID  string `json:"client_id"`
+}
+
+// CustomerLeft is sent when the customer disconnects from the meeting room
+type CustomerLeft struct {
+	MeetingID string `json:"meeting_id"`
+	ClientID  string `json:"client_id"`
+}
+
+// SessionStarted is sent when an agent starts the call
+type SessionStarted struct {
+	MeetingID string `json:"meeting_id"`
+	AgentID   uint   `json:"agent_id"`
+}
+
+// SessionClaimed is sent when an agent takes ownership of a meeting
+type SessionClaimed struct {
+	MeetingID string `json:"meeting_id"`
+	AgentID   uint   `json:"agent_id"`
+}
+
+// SessionCompleted is sent when the agent finishes the verification
+type SessionCompleted struct {
+	MeetingID string `json:"meeting_id"`
+	AgentID   uint   `json:"agent_id"`
+	Outcome   string `json:"outcome"`
+}
+
+// SessionFailed is sent when a call broke off without a verdict
+type SessionFailed struct {
+	MeetingID string `json:"meeting_id"`
+	AgentID   uint   `json:"agent_id"`
+	Reason    string `json:"reason"`
+}
+
+// NoShow is sent when the customer never turned up for the meeting
+type NoShow struct {
+	MeetingID   string    `json:"meeting_id"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// SessionRescheduled is sent when the meeting moves to another time
+type SessionRescheduled struct {
+	MeetingID           string    `json:"meeting_id"`
+	ScheduledAt         time.Time `json:"scheduled_at"`
+	PreviousScheduledAt time.Time `json:"previous_scheduled_at"`
+	By                  string    `json:"by"` // agent, customer
+}
+
+// SessionCancelled is sent when the meeting is called off
+type SessionCancelled struct {
+	MeetingID   string    `json:"meeting_id"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	By          string    `json:"by"` // agent, customer
+}
+
+func (MeetingRequest) EventType() string     { return EventMeetingRequest }
+func (CustomerJoined) EventType() string     { return EventCustomerJoined }
+func (CustomerLeft) EventType() string       { return EventCustomerLeft }
+func (SessionStarted) EventType() string     { return EventSessionStarted }
+func (SessionClaimed) EventType() string     { return EventSessionClaimed }
+func (SessionCompleted) EventType() string   { return EventSessionCompleted }
+func (SessionFailed) EventType() string      { return EventSessionFailed }
+func (NoShow) EventType() string             { return EventNoShow }
+func (SessionRescheduled) EventType() string { return EventRescheduled }
+func (SessionCancelled) EventType() string   { return EventCancelled }
+
+// Publish encodes the payload and sends it to staff allowed to see the session
+func Publish(session models.KYCSession, payload Payload) {
+	PublishToSkilled(session, payload, nil)
+}
+
+// PublishToSkilled is Publish restricted to agents holding every listed skill
+func PublishToSkilled(session models.KYCSession, payload Payload, skills []string) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", payload.EventType(), err)
+		return
+	}
+
+	publish(Event{
+		Type:      payload.EventType(),
+		MeetingID: session.MeetingID,
+		AgentID:   session.AgentID,
+		Queue:     session.Queue,
+		Skills:    skills,
+		Data:      string(data),
+	})
+}
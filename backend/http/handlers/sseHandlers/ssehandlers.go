@@ -1,39 +1,105 @@
 package sseHandlers
 
 import (
-	"fmt"
-	// "log"
+	"log"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/pii"
+	"kyc-backend/internal/presence"
+	"kyc-backend/internal/routing"
+
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 )
 
-// Global map: meetingID -> channel of notifications
+// eventLogSize bounds how many past events are kept for Last-Event-ID replay
+const eventLogSize = 1000
+
+// Event is a single notification pushed to connected staff. ID is assigned
+// when the event is persisted; MeetingID, AgentID and Queue are only used to
+// decide who receives it.
+type Event struct {
+	ID        uint
+	Type      string
+	MeetingID string
+	AgentID   *uint
+	Queue     string
+	Skills    []string
+	Data      string
+}
+
+// subscriber is one open SSE connection along with the filters it asked for
+type subscriber struct {
+	userID    uint
+	role      string
+	skills    []string
+	mine      bool
+	queue     string
+	meetingID string
+	ch        chan Event
+	lagged    chan struct{}
+	lagOnce   sync.Once
+}
+
+// Global set of connected staff streams
 var (
-	adminNotifiers = make(map[string]chan string)
-	notifierMutex  = &sync.RWMutex{}
-)
+	subscribers   = make(map[*subscriber]bool)
+	notifierMutex = &sync.RWMutex{}
 
+	// publishMutex keeps persisting and fan-out in ID order
+	publishMutex sync.Mutex
+)
 
-func SSEHandler(c *gin.Context) {
-	// ---- SSE / CORS headers ----
-	origin := c.GetHeader("Origin")
-	if origin == "" {
-		origin = "*" // fallback
+// wants reports whether the event is visible to this subscriber and matches
+// its query-string filters.
+func (s *subscriber) wants(ev Event) bool {
+	// Agents never see meetings assigned to someone else, except the claim
+	// notice itself so they can drop the request from their list
+	if s.role != models.RoleAdmin && ev.AgentID != nil && *ev.AgentID != s.userID && ev.Type != EventSessionClaimed {
+		return false
+	}
+	// New requests are only routed to agents who are free to take them
+	if s.role != models.RoleAdmin && ev.Type == EventMeetingRequest && ev.AgentID == nil && !presence.IsAvailable(s.userID) {
+		return false
 	}
+	if s.role != models.RoleAdmin && !routing.Matches(s.skills, ev.Skills) {
+		return false
+	}
+	if s.mine && (ev.AgentID == nil || *ev.AgentID != s.userID) {
+		return false
+	}
+	if s.queue != "" && ev.Queue != s.queue {
+		return false
+	}
+	if s.meetingID != "" && ev.MeetingID != s.meetingID {
+		return false
+	}
+	return true
+}
 
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-	c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
+// SSEHandler streams events to the authenticated agent or admin.
+//
+// Supported filters:
+//
+//	?mine=true        only meetings assigned to the caller
+//	?queue=general    only meetings in the given queue
+//	?meeting_id=...   only a single meeting
+//
+// On reconnect, events newer than the Last-Event-ID header (or the
+// last_event_id query parameter) are replayed from the event log first. If
+// some of them have already been pruned from the log, a reset event is sent
+// instead and the client should refetch its state.
+func SSEHandler(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
 
-	// Preflight OPTIONS (for CORS)
-	if c.Request.Method == http.MethodOptions {
-		c.AbortWithStatus(http.StatusNoContent)
+	var user models.User
+	if err := database.DB.Select("id", "role", "skills").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
 
@@ -44,16 +110,30 @@ func SSEHandler(c *gin.Context) {
 		return
 	}
 
-	// ---- Create a dedicated notification channel for this connection ----
-	notificationChan := make(chan string, 10)
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	// ---- Register a dedicated channel for this connection ----
+	sub := &subscriber{
+		userID:    user.ID,
+		role:      user.Role,
+		skills:    user.Skills,
+		mine:      c.Query("mine") == "true",
+		queue:     c.Query("queue"),
+		meetingID: c.Query("meeting_id"),
+		ch:        make(chan Event, 10),
+		lagged:    make(chan struct{}),
+	}
+
 	notifierMutex.Lock()
-	adminNotifiers["admin"] = notificationChan
+	subscribers[sub] = true
 	notifierMutex.Unlock()
 
 	defer func() {
 		notifierMutex.Lock()
-		delete(adminNotifiers, "admin")
-		close(notificationChan)
+		delete(subscribers, sub)
+		close(sub.ch)
 		notifierMutex.Unlock()
 	}()
 
@@ -63,13 +143,45 @@ func SSEHandler(c *gin.Context) {
 
 	// Send initial connection event
 	c.SSEvent("ping", "connected")
+
+	// Replay anything missed while disconnected. The subscriber is already
+	// registered, so events published meanwhile are deduplicated below.
+	lastID := lastEventID(c)
+	if lastID > 0 && !replayable(lastID) {
+		c.SSEvent(EventReset, "missed events are no longer available")
+
+		// Anything up to now is covered by the refetch
+		var newest models.AdminEvent
+		if err := database.DB.Select("id").Order("id desc").Limit(1).Find(&newest).Error; err != nil {
+			log.Printf("Failed to load newest SSE event for user %d: %v", user.ID, err)
+		}
+		lastID = newest.ID
+	} else if lastID > 0 {
+		var missed []models.AdminEvent
+		if err := database.DB.Where("id > ?", lastID).Order("id").Find(&missed).Error; err != nil {
+			log.Printf("Failed to load SSE replay for user %d: %v", user.ID, err)
+		}
+		for _, record := range missed {
+			ev := eventFromRecord(record)
+			if sub.wants(ev) {
+				writeEvent(c, ev)
+			}
+			lastID = ev.ID
+		}
+	}
 	flusher.Flush()
 
 	for {
 		select {
-		case msg := <-notificationChan:
-			c.SSEvent("meeting_request", msg)
+		case ev := <-sub.ch:
+			if ev.ID != 0 && ev.ID <= lastID {
+				continue
+			}
+			writeEvent(c, ev)
 			flusher.Flush()
+		case <-sub.lagged:
+			// Drop the stream; the browser reconnects with Last-Event-ID
+			return
 		case <-ticker.C:
 			c.SSEvent("ping", "keep-alive")
 			flusher.Flush()
@@ -79,79 +191,114 @@ func SSEHandler(c *gin.Context) {
 	}
 }
 
-// SSEHandler streams events to connected admins
-func SSEHandler_(c *gin.Context) {
-	// Optional: authenticate as admin (skip for prototype)
-	// c.Writer.Header().Set("Content-Type", "text/event-stream")
-	// c.Writer.Header().Set("Cache-Control", "no-cache")
-	// c.Writer.Header().Set("Connection", "keep-alive")
-	// c.Writer.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-
-	c.Writer.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-	c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
-
-	// Handle preflight (though SSE doesn't use it, some browsers check)
-	if c.Request.Method == "OPTIONS" {
-		c.AbortWithStatus(204)
-		return
+// lastEventID reads the resume point sent by a reconnecting client
+func lastEventID(c *gin.Context) uint {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
 	}
-
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0
 	}
+	return uint(id)
+}
 
-	// Create a channel for this connection
-	notificationChan := make(chan string, 10)
-	notifierMutex.Lock()
-	adminNotifiers["admin"] = notificationChan // or use multiple channels per agent later
-	notifierMutex.Unlock()
+// replayable reports whether every event after lastID is still in the log.
+// The log is pruned from the front, so that holds when the oldest row left
+// is no newer than lastID+1. An ID past the newest row means the log was
+// reset and the client's state is stale too.
+func replayable(lastID uint) bool {
+	var bounds struct {
+		Oldest uint
+		Newest uint
+	}
+	if err := database.DB.Model(&models.AdminEvent{}).
+		Select("COALESCE(MIN(id), 0) AS oldest, COALESCE(MAX(id), 0) AS newest").
+		Scan(&bounds).Error; err != nil {
+		log.Printf("Failed to check SSE event log: %v", err)
+		return false
+	}
+	if lastID > bounds.Newest {
+		return false
+	}
+	return bounds.Oldest <= lastID+1
+}
 
-	defer func() {
-		notifierMutex.Lock()
-		delete(adminNotifiers, "admin")
-		close(notificationChan)
-		notifierMutex.Unlock()
-	}()
+// writeEvent renders a single event including its id: line
+func writeEvent(c *gin.Context, ev Event) {
+	id := ""
+	if ev.ID != 0 {
+		id = strconv.FormatUint(uint64(ev.ID), 10)
+	}
+	c.Render(-1, sse.Event{Id: id, Event: ev.Type, Data: ev.Data})
+}
 
-	// Send heartbeat to keep connection alive
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+func eventFromRecord(record models.AdminEvent) Event {
+	return Event{
+		ID:        record.ID,
+		Type:      record.Type,
+		MeetingID: record.MeetingID,
+		AgentID:   record.AgentID,
+		Queue:     record.Queue,
+		Skills:    record.Skills,
+		Data:      record.Data,
+	}
+}
 
-	// Initial ping
-	c.SSEvent("ping", "connected")
-	flusher.Flush()
+// publish persists the event to the bounded log and delivers it to every
+// subscriber allowed to see it
+func publish(ev Event) {
+	publishMutex.Lock()
+	defer publishMutex.Unlock()
 
-	for {
-		select {
-		case msg := <-notificationChan:
-			c.SSEvent("meeting_request", msg)
-			flusher.Flush()
-		case <-ticker.C:
-			c.SSEvent("ping", "keep-alive")
-			flusher.Flush()
-		case <-c.Request.Context().Done():
-			return
+	record := models.AdminEvent{
+		Type:      ev.Type,
+		MeetingID: ev.MeetingID,
+		AgentID:   ev.AgentID,
+		Queue:     ev.Queue,
+		Skills:    ev.Skills,
+		Data:      ev.Data,
+	}
+	if err := database.DB.Create(&record).Error; err != nil {
+		log.Printf("Failed to persist %s event: %v", ev.Type, err)
+	} else {
+		ev.ID = record.ID
+		if record.ID > eventLogSize {
+			database.DB.Where("id <= ?", record.ID-eventLogSize).Delete(&models.AdminEvent{})
 		}
 	}
-}
 
-// NotifyAdmins sends a message to all connected admin SSE clients
-func NotifyAdmins(meetingID, nationalID string) {
-	msg := fmt.Sprintf(`{"meeting_id":"%s","national_id":"%s"}`, meetingID, nationalID)
 	notifierMutex.RLock()
 	defer notifierMutex.RUnlock()
 
-	for _, ch := range adminNotifiers {
+	for sub := range subscribers {
+		if !sub.wants(ev) {
+			continue
+		}
 		select {
-		case ch <- msg:
+		case sub.ch <- ev:
 		default:
-			// Skip if channel is full
+			// Subscriber fell behind; make it reconnect and replay
+			sub.lagOnce.Do(func() { close(sub.lagged) })
 		}
 	}
-}
\ No newline at end of file
+}
+
+// NotifyAdmins tells connected staff that a customer is waiting in the
+// session. Only agents with the required skills are told; a fallback request
+// goes to the general pool.
+func NotifyAdmins(session models.KYCSession, requiredSkills []string, fallback bool) {
+	payload := MeetingRequest{
+		MeetingID:      session.MeetingID,
+		NationalID:     pii.MaskNationalID(session.Customer.NationalID),
+		CustomerName:   session.Customer.FullName,
+		ScheduledAt:    session.ScheduledAt,
+		RequiredSkills: requiredSkills,
+		Fallback:       fallback,
+	}
+	if fallback {
+		requiredSkills = nil
+	}
+	PublishToSkilled(session, payload, requiredSkills)
+}
@@ -0,0 +1,150 @@
+package webhookHandlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func CreateSubscription(c *gin.Context) {
+	var body struct {
+		URL    string   `json:"url" binding:"required"`
+		Events []string `json:"events"`
+		Secret string   `json:"secret"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if u, err := url.Parse(body.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook URL"})
+		return
+	}
+
+	for _, e := range body.Events {
+		if !knownEvent(e) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type: " + e})
+			return
+		}
+	}
+
+	// Generate a signing secret unless the caller brought one
+	secret := body.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhooks.NewSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+			return
+		}
+	}
+
+	sub := models.WebhookSubscription{
+		URL:    body.URL,
+		Secret: secret,
+		Events: body.Events,
+		Active: true,
+	}
+
+	if err := database.DB.Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	}
+
+	// The secret is only ever shown here
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Subscription created",
+		"subscription": sub,
+		"secret":       secret,
+	})
+}
+
+func ListSubscriptions(c *gin.Context) {
+	var subs []models.WebhookSubscription
+	if err := database.DB.Order("id").Find(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+func DeleteSubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	// Deactivate rather than delete so the delivery log stays intact
+	result := database.DB.Model(&models.WebhookSubscription{}).Where("id = ?", id).Update("active", false)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription deactivated"})
+}
+
+func ListDeliveries(c *gin.Context) {
+	query := database.DB.Order("id desc").Limit(100)
+	if sub := c.Query("subscription_id"); sub != "" {
+		query = query.Where("subscription_id = ?", sub)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func RedeliverDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := webhooks.Redeliver(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue redelivery"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Redelivery queued", "delivery": delivery})
+}
+
+func knownEvent(eventType string) bool {
+	switch eventType {
+	case webhooks.EventProfileSubmitted, webhooks.EventMeetingScheduled,
+		webhooks.EventMeetingRescheduled, webhooks.EventMeetingCancelled,
+		webhooks.EventSessionStarted, webhooks.EventSessionCompleted, webhooks.EventSessionFailed,
+		webhooks.EventStatusChanged, webhooks.EventDuplicateSuspected,
+		webhooks.EventCustomerMerged:
+		return true
+	}
+	return false
+}
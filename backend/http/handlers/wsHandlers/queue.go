@@ -0,0 +1,32 @@
+package wsHandlers
+
+import (
+	"log"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/waitingroom"
+)
+
+// PushQueuePositions sends every waiting customer their current position and
+// estimated wait over their room connection.
+func PushQueuePositions() {
+	positions := waitingroom.Positions(config.AVG_SESSION_DURATION)
+
+	for _, p := range positions {
+		for _, client := range members(p.MeetingID, nil) {
+			if client.Role != "customer" {
+				continue
+			}
+			err := client.send(map[string]interface{}{
+				"event":                  "queue-position",
+				"meeting_id":             p.MeetingID,
+				"position":               p.Position,
+				"queue_length":           p.QueueLength,
+				"estimated_wait_seconds": int(p.EstimatedWait.Seconds()),
+			})
+			if err != nil {
+				log.Printf("Failed to send queue position to %s: %v", client.RemoteAddr, err)
+			}
+		}
+	}
+}
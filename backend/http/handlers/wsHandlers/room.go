@@ -0,0 +1,19 @@
+package wsHandlers
+
+import "log"
+
+// EndRoom tells everyone in the meeting room that the call is over and
+// disconnects them. Their read loops then clean up as usual.
+func EndRoom(meetingID, outcome string) {
+	for _, client := range members(meetingID, nil) {
+		err := client.send(map[string]interface{}{
+			"event":      "meeting-ended",
+			"meeting_id": meetingID,
+			"outcome":    outcome,
+		})
+		if err != nil {
+			log.Printf("Failed to notify %s of meeting end: %v", client.RemoteAddr, err)
+		}
+		client.Conn.Close()
+	}
+}
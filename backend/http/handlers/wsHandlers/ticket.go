@@ -0,0 +1,26 @@
+package wsHandlers
+
+import (
+	"net/http"
+
+	"kyc-backend/internal/wsticket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IssueTicket hands a logged-in user a single-use ticket for opening the
+// WebSocket as ws?ticket=<ticket>. It expires after wsticket.TTL.
+func IssueTicket(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	ticket, err := wsticket.Issue(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket":     ticket,
+		"expires_in": int(wsticket.TTL.Seconds()),
+	})
+}
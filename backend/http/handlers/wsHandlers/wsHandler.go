@@ -5,6 +5,18 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+
+	"kyc-backend/config"
+	"kyc-backend/http/handlers/sseHandlers"
+	"kyc-backend/http/middleware"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/jointoken"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/presence"
+	"kyc-backend/internal/waitingroom"
+	"kyc-backend/internal/wsticket"
 
 	// "github.com/coder/websocket/wsjson"
 	"github.com/gin-gonic/gin"
@@ -18,19 +30,38 @@ type Client struct {
 	ID         string
 	Role       string
 	RemoteAddr string
+	UserID     uint // set for authenticated staff, zero for customers
+
+	// gorilla/websocket allows only one concurrent writer per connection
+	writeMutex sync.Mutex
 }
 
 // Global rooms map: roomID -> set of clients
-var rooms = make(map[string]map[*Client]bool)
+var (
+	rooms      = make(map[string]map[*Client]bool)
+	roomsMutex = &sync.RWMutex{}
+)
 
 // Configure the upgrader
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow requests from your frontend origin
-		origin := r.Header.Get("Origin")
+	CheckOrigin: allowedOrigin,
+}
+
+// allowedOrigin accepts browsers on one of ALLOWED_ORIGINS, so other sites
+// cannot open a socket with the staff cookie. Clients that send no Origin
+// are not browsers and carry no ambient credentials.
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
 		return true
-		return origin == "http://localhost:5173"
-	},
+	}
+	for _, allowed := range config.ALLOWED_ORIGINS {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	log.Printf("Rejected WebSocket from origin %q", origin)
+	return false
 }
 
 // WebSocketHandler upgrades the HTTP connection to WebSocket
@@ -48,6 +79,15 @@ func WebSocketHandler(c *gin.Context) {
 		RemoteAddr: c.ClientIP(),
 	}
 
+	// Staff identify themselves with a single-use ticket from IssueTicket,
+	// since browsers cannot set headers on a WebSocket, or with the auth
+	// cookie. The login token itself is never taken from the URL.
+	if userID, ok := wsticket.Redeem(c.Query("ticket")); ok {
+		client.UserID = userID
+	} else if userID, err := middleware.ParseToken(middleware.TokenFromRequest(c)); err == nil {
+		client.UserID = userID
+	}
+
 	log.Println("New WebSocket client connected:", client.RemoteAddr)
 
 	// Main message loop
@@ -80,40 +120,63 @@ func WebSocketHandler(c *gin.Context) {
 		case "join-room":
 			roomID, ok1 := msg["room"].(string)
 			clientID, ok2 := msg["id"].(string)
-			role := msg["role"].(string)
+			role, _ := msg["role"].(string)
 			if !ok1 || !ok2 {
 				log.Printf("Missing 'room' or 'id' in join-room from %s", client.RemoteAddr)
 				continue
 			}
 
+			// Customers prove themselves with their meeting link's token,
+			// everyone else must be logged in
+			joinToken, _ := msg["token"].(string)
+			if reason := authorizeJoin(roomID, role, joinToken, client.UserID); reason != "" {
+				log.Printf("Rejected join-room %s as %q from %s: %s", roomID, role, client.RemoteAddr, reason)
+				client.send(map[string]interface{}{
+					"event": "join-rejected",
+					"error": reason,
+				})
+				continue
+			}
+
 			client.Room = roomID
 			client.ID = clientID
 			client.Role = role
 
+			roomsMutex.Lock()
 			// Initialize room if needed
 			if rooms[roomID] == nil {
 				rooms[roomID] = make(map[*Client]bool)
 			}
 			rooms[roomID][client] = true
+			roomsMutex.Unlock()
 
 			// Notify others in the room
-			for otherClient := range rooms[roomID] {
-				if otherClient != client {
-					notification := map[string]interface{}{
-						"event": "user-joined",
-						"id":    client.ID,
-					}
-					if err := sendJSON(otherClient.Conn, notification); err != nil {
-						log.Printf("Failed to notify client: %v", err)
-					}
+			for _, otherClient := range members(roomID, client) {
+				notification := map[string]interface{}{
+					"event": "user-joined",
+					"id":    client.ID,
+				}
+				if err := otherClient.send(notification); err != nil {
+					log.Printf("Failed to notify client: %v", err)
+				}
+			}
+
+			if client.Role == "customer" {
+				notifyStaff(roomID, sseHandlers.CustomerJoined{MeetingID: roomID, ClientID: clientID})
+				PushQueuePositions()
+			}
+
+			// An agent in a room is on a call
+			if client.Role == "agent" && client.UserID != 0 {
+				if err := presence.Set(client.UserID, models.AgentBusy); err != nil {
+					log.Printf("Failed to mark agent %d busy: %v", client.UserID, err)
 				}
 			}
 		case "start_meeting":
 			// Forward to customer in the same room
-			room := rooms[client.Room]
-			for otherClient := range room {
+			for _, otherClient := range members(client.Room, client) {
 				if otherClient.Role == "customer" {
-					sendJSON(otherClient.Conn, map[string]interface{}{
+					otherClient.send(map[string]interface{}{
 						"event":      "start_meeting",
 						"meeting_id": client.Room,
 					})
@@ -131,22 +194,15 @@ func WebSocketHandler(c *gin.Context) {
 				continue
 			}
 
-			room := rooms[client.Room]
-			for otherClient := range room {
-				if otherClient != client {
-					if err := sendJSON(otherClient.Conn, msg); err != nil {
-						log.Printf("Failed to forward signal: %v", err)
-					}
+			for _, otherClient := range members(client.Room, client) {
+				if err := otherClient.send(msg); err != nil {
+					log.Printf("Failed to forward signal: %v", err)
 				}
 			}
 
 		case "offer", "answer", "ice-candidate":
-			if room, exists := rooms[client.Room]; exists {
-				for otherClient := range room {
-					if otherClient != client {
-						sendJSON(otherClient.Conn, msg)
-					}
-				}
+			for _, otherClient := range members(client.Room, client) {
+				otherClient.send(msg)
 			}
 
 		default:
@@ -159,9 +215,28 @@ func WebSocketHandler(c *gin.Context) {
 	log.Println("Client disconnected:", client.RemoteAddr)
 }
 
-// sendJSON sends a JSON message over the WebSocket
-func sendJSON(conn *websocket.Conn, data interface{}) error {
-	return conn.WriteJSON(data)
+// send writes a JSON message to the client's WebSocket. Writes to other
+// clients are not held up by it.
+func (client *Client) send(data interface{}) error {
+	client.writeMutex.Lock()
+	defer client.writeMutex.Unlock()
+	return client.Conn.WriteJSON(data)
+}
+
+// members returns the clients in a room other than except. Callers send to
+// them after the rooms lock is released, so a slow socket cannot stall
+// joins and leaves everywhere.
+func members(roomID string, except *Client) []*Client {
+	roomsMutex.RLock()
+	defer roomsMutex.RUnlock()
+
+	var list []*Client
+	for client := range rooms[roomID] {
+		if client != except {
+			list = append(list, client)
+		}
+	}
+	return list
 }
 
 // cleanupClient removes the client from its room
@@ -170,24 +245,121 @@ func cleanupClient(client *Client) {
 		return
 	}
 
+	roomsMutex.Lock()
 	room := rooms[client.Room]
 	if room == nil {
+		roomsMutex.Unlock()
 		return
 	}
 
 	delete(room, client)
 
-	// Optionally notify others that user left
+	var others []*Client
+	customerStillHere := false
 	for otherClient := range room {
-		notification := map[string]interface{}{
-			"event": "user-left",
-			"id":    client.ID,
+		others = append(others, otherClient)
+		if otherClient.Role == "customer" {
+			customerStillHere = true
 		}
-		sendJSON(otherClient.Conn, notification)
 	}
 
 	// Clean up empty rooms
 	if len(room) == 0 {
 		delete(rooms, client.Room)
 	}
+	roomsMutex.Unlock()
+
+	// Optionally notify others that user left
+	for _, otherClient := range others {
+		notification := map[string]interface{}{
+			"event": "user-left",
+			"id":    client.ID,
+		}
+		otherClient.send(notification)
+	}
+
+	if client.Role == "customer" {
+		notifyStaff(client.Room, sseHandlers.CustomerLeft{MeetingID: client.Room, ClientID: client.ID})
+
+		// A customer who closed the page is no longer waiting
+		if !customerStillHere && waitingroom.Leave(client.Room) {
+			PushQueuePositions()
+		}
+	}
+
+	if client.Role == "agent" && client.UserID != 0 {
+		releaseAgent(client.UserID, client.Room)
+	}
+}
+
+// releaseAgent makes the agent available again once the meeting they left is
+// over. Leaving an ongoing call (e.g. moving to the call page) keeps them busy.
+func releaseAgent(userID uint, meetingID string) {
+	var session models.KYCSession
+	if err := database.DB.Select("status").Where("meeting_id = ?", meetingID).First(&session).Error; err == nil {
+		if session.Status == models.SessionScheduled || session.Status == models.SessionOngoing {
+			return
+		}
+	}
+
+	if presence.Get(userID) != models.AgentBusy {
+		return
+	}
+	if err := presence.Set(userID, models.AgentAvailable); err != nil {
+		log.Printf("Failed to mark agent %d available: %v", userID, err)
+	}
+}
+
+// notifyStaff publishes an admin event for the meeting behind a room
+func notifyStaff(meetingID string, payload sseHandlers.Payload) {
+	var session models.KYCSession
+	if err := database.DB.Where("meeting_id = ?", meetingID).First(&session).Error; err != nil {
+		log.Printf("No session for room %s, skipping %s event", meetingID, payload.EventType())
+		return
+	}
+	sseHandlers.Publish(session, payload)
+}
+
+// authorizeJoin returns why the join is refused, or "" when it is allowed.
+// Only meetings that are scheduled or under way can be joined; staff must be
+// the assigned agent or an admin.
+func authorizeJoin(meetingID, role, joinToken string, userID uint) string {
+	if role != "customer" && userID == 0 {
+		return "Login required"
+	}
+
+	var session models.KYCSession
+	if err := database.DB.Where("meeting_id = ?", meetingID).First(&session).Error; err != nil {
+		return "Meeting not found"
+	}
+	if session.Status != models.SessionScheduled && session.Status != models.SessionOngoing {
+		return "Meeting is " + session.Status
+	}
+
+	if role != "customer" {
+		if !assignedOrAdmin(session, userID) {
+			return "Meeting is not assigned to you"
+		}
+		return ""
+	}
+
+	if _, err := jointoken.Verify(joinToken, session); err != nil {
+		_, msg := jointoken.Reject(err)
+		return msg
+	}
+	return ""
+}
+
+// assignedOrAdmin is the rule of the staff session endpoints: the assigned
+// agent or an admin
+func assignedOrAdmin(session models.KYCSession, userID uint) bool {
+	if session.AgentID != nil && *session.AgentID == userID {
+		return true
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.Role == models.RoleAdmin
 }
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"strings"
@@ -9,6 +10,15 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Errors returned by ParseToken, worded for API responses
+var (
+	ErrNoToken       = errors.New("No auth token")
+	ErrInvalidToken  = errors.New("Invalid token")
+	ErrTokenNotValid = errors.New("Token is not valid")
+	ErrInvalidClaims = errors.New("Invalid token claims")
+	ErrInvalidUserID = errors.New("Invalid user ID in token")
+)
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Handle OPTIONS preflight requests
@@ -17,70 +27,75 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		var tokenString string
-
-		// Try to get token from Authorization header first
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
-			// Check if it's a Bearer token
-			parts := strings.Split(authHeader, " ")
-			if len(parts) == 2 && parts[0] == "Bearer" {
-				tokenString = parts[1]
-			}
-		}
-
-		// If no Authorization header, try cookie
-		if tokenString == "" {
-			var err error
-			tokenString, err = c.Cookie("auth_token")
-			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "No auth token"})
-				c.Abort()
-				return
-			}
-		}
-
-		// Parse and validate JWT
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verify signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
+		userID, err := ParseToken(TokenFromRequest(c))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid"})
-			c.Abort()
-			return
-		}
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
+// TokenFromRequest returns the JWT from the Authorization header, falling back
+// to the auth_token cookie
+func TokenFromRequest(c *gin.Context) string {
+	// Try to get token from Authorization header first
+	authHeader := c.GetHeader("Authorization")
+	if authHeader != "" {
+		// Check if it's a Bearer token
+		parts := strings.Split(authHeader, " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			return parts[1]
 		}
+	}
 
-		// Get user ID from claims
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
-			c.Abort()
-			return
+	// If no Authorization header, try cookie
+	tokenString, err := c.Cookie("auth_token")
+	if err != nil {
+		return ""
+	}
+	return tokenString
+}
+
+// ParseToken validates the JWT and returns the user ID it was issued for
+func ParseToken(tokenString string) (uint, error) {
+	if tokenString == "" {
+		return 0, ErrNoToken
+	}
+
+	// Parse and validate JWT
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Verify signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
 		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
 
-		userID := uint(userIDFloat)
-		c.Set("user_id", userID)
-		c.Next()
+	if err != nil {
+		return 0, ErrInvalidToken
 	}
+
+	if !token.Valid {
+		return 0, ErrTokenNotValid
+	}
+
+	// Extract claims
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, ErrInvalidClaims
+	}
+
+	// Get user ID from claims
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, ErrInvalidUserID
+	}
+
+	return uint(userIDFloat), nil
 }
 
 func AuthMiddleware__() gin.HandlerFunc {
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminOnly rejects authenticated users who are not admins. It must run after
+// AuthMiddleware.
+func AdminOnly() gin.HandlerFunc {
+	return requireRole("Admin access required", models.RoleAdmin)
+}
+
+// StaffOnly rejects authenticated users who are neither agents nor admins,
+// such as accounts that registered themselves and were never given a role.
+// It must run after AuthMiddleware.
+func StaffOnly() gin.HandlerFunc {
+	return requireRole("Staff access required", models.RoleAgent, models.RoleAdmin)
+}
+
+func requireRole(message string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		var user models.User
+		if err := database.DB.Select("id", "role").First(&user, userID).Error; err != nil || !slices.Contains(roles, user.Role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": message})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
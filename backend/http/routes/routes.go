@@ -1,9 +1,14 @@
 package routes
 
 import (
+	"kyc-backend/config"
+	"kyc-backend/http/handlers/agentHandlers"
 	"kyc-backend/http/handlers/authHandlers"
+	"kyc-backend/http/handlers/branchHandlers"
+	"kyc-backend/http/handlers/customerHandlers"
 	"kyc-backend/http/handlers/kycHandlers"
 	"kyc-backend/http/handlers/sseHandlers"
+	"kyc-backend/http/handlers/webhookHandlers"
 	"kyc-backend/http/handlers/wsHandlers"
 	"kyc-backend/http/middleware"
 	"time"
@@ -14,10 +19,7 @@ import (
 func Setup(router *gin.Engine) {
     // Put CORS as early as possible
     router.Use(cors.New(cors.Config{
-        AllowOrigins: []string{
-            "http://localhost:5173",          // Vite dev
-            "https://test-kyc-app.duckdns.org",      // prod
-        },
+        AllowOrigins: config.ALLOWED_ORIGINS, // Vite dev and prod unless configured
         // AllowOriginFunc: func(origin string) bool {
         //     return true // ← still ok for dev
         //     // Later: return origin == "http://localhost:3000" || origin == "https://your-frontend.com"
@@ -29,8 +31,6 @@ func Setup(router *gin.Engine) {
         MaxAge:           12 * time.Hour,
     }))
 
-	router.GET("/api/sse", sseHandlers.SSEHandler) // ← add this
-
     // WebSocket - no cors needed usually
     router.GET("/ws", wsHandlers.WebSocketHandler)
 
@@ -39,8 +39,14 @@ func Setup(router *gin.Engine) {
         // Public routes
         api.POST("/kyc/submit", kycHandlers.SubmitKYCProfile)
         api.POST("/kyc/schedule", kycHandlers.ScheduleKYCMeeting)
+        api.GET("/kyc/slots", kycHandlers.ListSlots)
 		api.POST("/kyc/notify-admin", kycHandlers.NotifyAdmin) // ← add this
         api.GET("/kyc/meeting/:meetingId", kycHandlers.GetKYCMeeting)
+        api.GET("/kyc/meeting/:meetingId/status", sseHandlers.CustomerStatusHandler)
+        api.GET("/kyc/meeting/:meetingId/invite.ics", kycHandlers.DownloadMeetingInvite)
+        api.POST("/kyc/meeting/:meetingId/documents/:kind", kycHandlers.UploadDocument)
+        api.POST("/kyc/meeting/:meetingId/reschedule", kycHandlers.CustomerRescheduleMeeting)
+        api.POST("/kyc/meeting/:meetingId/cancel", kycHandlers.CustomerCancelMeeting)
         api.POST("/register", authHandlers.Register)
         api.POST("/login", authHandlers.Login)
         api.POST("/logout", authHandlers.Logout)
@@ -48,7 +54,54 @@ func Setup(router *gin.Engine) {
         protected := api.Group("/")
         protected.Use(middleware.AuthMiddleware())
         protected.GET("/profile", authHandlers.Profile)
-		protected.POST("/kyc/session/:meetingId/start", kycHandlers.StartKYCSession)
+
+		// Agents and admins only
+		staff := protected.Group("/")
+		staff.Use(middleware.StaffOnly())
+		staff.GET("/sse", sseHandlers.SSEHandler)
+		staff.POST("/ws-ticket", wsHandlers.IssueTicket)
+		staff.GET("/agent/status", agentHandlers.GetAgentStatus)
+		staff.PUT("/agent/status", agentHandlers.SetAgentStatus)
+		staff.GET("/agent/skills", agentHandlers.GetAgentSkills)
+		staff.GET("/agent/hours", agentHandlers.GetWorkingHours)
+		staff.GET("/kyc/queue", kycHandlers.ListWaitingQueue)
+		staff.POST("/kyc/queue/next", kycHandlers.NextInQueue)
+		staff.POST("/kyc/session/:meetingId/claim", kycHandlers.ClaimKYCSession)
+		staff.POST("/kyc/session/:meetingId/start", kycHandlers.StartKYCSession)
+		staff.POST("/kyc/session/:meetingId/complete", kycHandlers.CompleteKYCSession)
+		staff.POST("/kyc/session/:meetingId/no-show", kycHandlers.MarkNoShow)
+		staff.POST("/kyc/session/:meetingId/fail", kycHandlers.FailKYCSession)
+		staff.POST("/kyc/session/:meetingId/reschedule", kycHandlers.RescheduleKYCSession)
+		staff.POST("/kyc/session/:meetingId/cancel", kycHandlers.CancelKYCSession)
+		staff.GET("/kyc/session/:meetingId", kycHandlers.GetSessionDetails)
+		staff.POST("/kyc/session/:meetingId/reveal", kycHandlers.RevealPII)
+		staff.GET("/kyc/session/:meetingId/history", kycHandlers.GetSessionHistory)
+		staff.GET("/kyc/session/:meetingId/documents/:kind", kycHandlers.GetDocument)
+		staff.POST("/kyc/session/:meetingId/mrz", kycHandlers.VerifyMRZ)
+		staff.POST("/kyc/session/:meetingId/licence", kycHandlers.VerifyLicence)
+		staff.GET("/kyc/session/:meetingId/extractions", kycHandlers.ListExtractions)
+
+		admin := staff.Group("/")
+		admin.Use(middleware.AdminOnly())
+		admin.PUT("/agents/:userId/role", agentHandlers.SetAgentRole)
+		admin.PUT("/agents/:userId/skills", agentHandlers.SetAgentSkills)
+		admin.PUT("/agents/:userId/hours", agentHandlers.SetWorkingHours)
+		admin.PUT("/agents/:userId/branch", agentHandlers.SetAgentBranch)
+		admin.GET("/branches", branchHandlers.ListBranches)
+		admin.POST("/branches", branchHandlers.CreateBranch)
+		admin.PUT("/branches/:id/hours", branchHandlers.SetBusinessHours)
+		admin.GET("/branches/:id/holidays", branchHandlers.ListHolidays)
+		admin.POST("/branches/:id/holidays/import", branchHandlers.ImportHolidays)
+		admin.DELETE("/branches/:id/holidays/:date", branchHandlers.DeleteHoliday)
+		admin.GET("/duplicates", customerHandlers.ListDuplicates)
+		admin.POST("/duplicates/:id/dismiss", customerHandlers.DismissDuplicate)
+		admin.POST("/duplicates/:id/merge", customerHandlers.MergeDuplicate)
+		admin.POST("/customers/:id/merge", customerHandlers.MergeCustomers)
+		admin.GET("/webhooks", webhookHandlers.ListSubscriptions)
+		admin.POST("/webhooks", webhookHandlers.CreateSubscription)
+		admin.DELETE("/webhooks/:id", webhookHandlers.DeleteSubscription)
+		admin.GET("/webhooks/deliveries", webhookHandlers.ListDeliveries)
+		admin.POST("/webhooks/deliveries/:id/redeliver", webhookHandlers.RedeliverDelivery)
     }
     
     router.GET("/", func(c *gin.Context) {
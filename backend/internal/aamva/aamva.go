@@ -0,0 +1,288 @@
+// Package aamva parses the AAMVA DL/ID card data format: the text a PDF417
+// reader decodes from the back of a US or Canadian driver's licence or ID
+// card. The header lists the subfiles; each subfile is a run of elements,
+// a three-letter ID followed by the value and a line feed.
+//
+// Scanners are often careless with the control characters and offsets, so
+// subfiles that are not where the header says are searched for instead.
+package aamva
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrFormat is returned for text that is not an AAMVA payload
+var ErrFormat = errors.New("not an AAMVA DL/ID payload")
+
+// Subfile types holding the card data; jurisdictions add their own "Z" ones
+const (
+	SubfileDL = "DL"
+	SubfileID = "ID"
+)
+
+// Address is the cardholder's address as printed on the card
+type Address struct {
+	Street1      string `json:"street1,omitempty"`
+	Street2      string `json:"street2,omitempty"`
+	City         string `json:"city,omitempty"`
+	Jurisdiction string `json:"jurisdiction,omitempty"` // state or province, e.g. "CA"
+	PostalCode   string `json:"postal_code,omitempty"`
+}
+
+// Licence is the decoded card
+type Licence struct {
+	IIN                 string `json:"iin"` // issuer identification number
+	Version             int    `json:"version"`
+	JurisdictionVersion int    `json:"jurisdiction_version,omitempty"`
+
+	// Every element of every subfile, keyed by subfile type and element ID
+	Subfiles map[string]map[string]string `json:"subfiles"`
+
+	DocumentType  string     `json:"document_type"` // DL or ID
+	Jurisdiction  string     `json:"jurisdiction"`  // DAJ, the issuing state or province
+	Country       string     `json:"country,omitempty"`
+	LicenceNumber string     `json:"licence_number"`
+	FamilyName    string     `json:"family_name"`
+	FirstName     string     `json:"first_name"`
+	MiddleNames   string     `json:"middle_names,omitempty"`
+	BirthDate     *time.Time `json:"birth_date,omitempty"`
+	IssueDate     *time.Time `json:"issue_date,omitempty"`
+	ExpiryDate    *time.Time `json:"expiry_date,omitempty"`
+	Sex           string     `json:"sex,omitempty"` // M, F or X
+	Address       Address    `json:"address"`
+
+	// Set when the card had to shorten a name to fit
+	FamilyNameTruncated bool `json:"family_name_truncated,omitempty"`
+	FirstNameTruncated  bool `json:"first_name_truncated,omitempty"`
+}
+
+// designator is one subfile entry of the header
+type designator struct {
+	kind   string
+	offset int
+	length int
+}
+
+// Parse decodes an AAMVA payload
+func Parse(payload string) (*Licence, error) {
+	start := strings.Index(payload, "ANSI ")
+	prefix := len("ANSI ")
+	if start < 0 {
+		// Cards from before the 2000 standard
+		start = strings.Index(payload, "AAMVA")
+		prefix = len("AAMVA")
+	}
+	if start < 0 {
+		return nil, ErrFormat
+	}
+	header := payload[start+prefix:]
+
+	licence := &Licence{Subfiles: map[string]map[string]string{}}
+	var err error
+	if licence.IIN, header, err = digits(header, 6); err != nil {
+		return nil, err
+	}
+	var version string
+	if version, header, err = digits(header, 2); err != nil {
+		return nil, err
+	}
+	licence.Version, _ = strconv.Atoi(version)
+	if licence.Version >= 2 {
+		var jurisdiction string
+		if jurisdiction, header, err = digits(header, 2); err != nil {
+			return nil, err
+		}
+		licence.JurisdictionVersion, _ = strconv.Atoi(jurisdiction)
+	}
+	var entries string
+	if entries, header, err = digits(header, 2); err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(entries)
+
+	var designators []designator
+	for i := 0; i < count; i++ {
+		if len(header) < 10 {
+			return nil, ErrFormat
+		}
+		// Four-digit offset and length; a subfile holds at least its type
+		if _, _, err := digits(header[2:], 8); err != nil {
+			return nil, err
+		}
+		offset, _ := strconv.Atoi(header[2:6])
+		length, _ := strconv.Atoi(header[6:10])
+		if length < len(SubfileDL) {
+			return nil, ErrFormat
+		}
+		designators = append(designators, designator{kind: header[:2], offset: offset, length: length})
+		header = header[10:]
+	}
+	body := payload[len(payload)-len(header):]
+
+	for _, d := range designators {
+		if elements := subfile(payload, body, d); elements != nil {
+			licence.Subfiles[d.kind] = elements
+		}
+	}
+
+	for _, kind := range []string{SubfileDL, SubfileID} {
+		if elements := licence.Subfiles[kind]; len(elements) > 0 {
+			licence.DocumentType = kind
+			licence.fill(elements)
+			return licence, nil
+		}
+	}
+	return nil, ErrFormat
+}
+
+// subfile finds and splits one subfile. The offset counts from the start of
+// the payload, but if the type is not there it is looked for after the
+// header instead.
+func subfile(payload, body string, d designator) map[string]string {
+	var text string
+	if d.offset+len(d.kind) <= len(payload) && payload[d.offset:d.offset+len(d.kind)] == d.kind {
+		end := min(d.offset+d.length, len(payload))
+		text = payload[d.offset:end]
+	} else if i := strings.Index(body, d.kind); i >= 0 {
+		text = body[i:]
+	} else {
+		return nil
+	}
+
+	text = text[len(d.kind):]
+	if end := strings.IndexByte(text, '\r'); end >= 0 {
+		text = text[:end]
+	}
+
+	elements := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 3 || !isElementID(line[:3]) {
+			continue
+		}
+		elements[line[:3]] = strings.TrimSpace(line[3:])
+	}
+	return elements
+}
+
+// fill sets the named fields from the card's elements, covering the names
+// used by the older versions of the standard
+func (l *Licence) fill(e map[string]string) {
+	l.LicenceNumber = e["DAQ"]
+	l.Jurisdiction = e["DAJ"]
+	l.Country = e["DCG"]
+	l.Sex = sex(e["DBC"])
+	l.Address = Address{
+		Street1:      e["DAG"],
+		Street2:      e["DAH"],
+		City:         e["DAI"],
+		Jurisdiction: e["DAJ"],
+		PostalCode:   postalCode(e["DAK"]),
+	}
+
+	l.FamilyName = first(e["DCS"], e["DAB"])
+	l.FirstName = first(e["DAC"], e["DCT"])
+	l.MiddleNames = e["DAD"]
+	if l.FamilyName == "" && e["DAA"] != "" {
+		// Version 1: "FAMILY,FIRST,MIDDLE"
+		parts := strings.Split(e["DAA"], ",")
+		l.FamilyName = strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			l.FirstName = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			l.MiddleNames = strings.TrimSpace(strings.Join(parts[2:], " "))
+		}
+	}
+	if l.MiddleNames == "" {
+		// DCT holds all given names, comma or space separated
+		given := strings.Fields(strings.ReplaceAll(l.FirstName, ",", " "))
+		if len(given) > 1 {
+			l.FirstName, l.MiddleNames = given[0], strings.Join(given[1:], " ")
+		}
+	}
+	l.FamilyNameTruncated = e["DDE"] == "T"
+	l.FirstNameTruncated = e["DDF"] == "T"
+
+	l.BirthDate = l.date(e["DBB"])
+	l.IssueDate = l.date(e["DBD"])
+	l.ExpiryDate = l.date(e["DBA"])
+}
+
+// date reads an element date: US cards write MMDDCCYY and Canadian ones,
+// like every card of the first version, CCYYMMDD. The other order is tried
+// when the first does not parse.
+func (l *Licence) date(value string) *time.Time {
+	if len(value) != 8 {
+		return nil
+	}
+	layouts := []string{"01022006", "20060102"}
+	if l.Country == "CAN" || l.Version < 2 {
+		layouts[0], layouts[1] = layouts[1], layouts[0]
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func digits(s string, n int) (string, string, error) {
+	if len(s) < n {
+		return "", s, ErrFormat
+	}
+	for i := 0; i < n; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return "", s, ErrFormat
+		}
+	}
+	return s[:n], s[n:], nil
+}
+
+func isElementID(id string) bool {
+	for i := 0; i < len(id); i++ {
+		if id[i] < 'A' || id[i] > 'Z' {
+			return false
+		}
+	}
+	return id[0] == 'D' || id[0] == 'Z'
+}
+
+func sex(code string) string {
+	switch code {
+	case "1", "M":
+		return "M"
+	case "2", "F":
+		return "F"
+	case "":
+		return ""
+	}
+	return "X"
+}
+
+// postalCode writes a nine-digit ZIP as ZIP+4, dropping an empty "+4"
+func postalCode(code string) string {
+	code = strings.TrimSpace(code)
+	if len(code) == 9 {
+		if _, err := strconv.Atoi(code); err == nil {
+			if code[5:] == "0000" {
+				return code[:5]
+			}
+			return code[:5] + "-" + code[5:]
+		}
+	}
+	return code
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
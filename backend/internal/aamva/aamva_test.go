@@ -0,0 +1,231 @@
+package aamva
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// compliance is the standard's header prefix: "@", LF, RS, CR
+const compliance = "@\n\x1e\r"
+
+// virginia follows the sample card in the AAMVA DL/ID card design standard
+var virginia = compliance + "ANSI 636000090002DL00410278ZV03190008" +
+	"DLDAQT64235789\nDCSSAMPLE\nDDEN\nDACMICHAEL\nDDFN\nDADJOHN\nDDGN\nDCUJR\nDCAD\nDCBK\nDCDPH\n" +
+	"DBD06062016\nDBB06061986\nDBA12102024\nDBC1\nDAU068 in\nDAYBRO\nDAG2300 WEST BROAD STREET\n" +
+	"DAIRICHMOND\nDAJVA\nDAK232690000  \nDCF2424244747474786102204\nDCGUSA\nDCK123456789\n" +
+	"DDAF\nDDB06062008\nDDC06062009\nDDD1\r" +
+	"ZVZVA01\r"
+
+// ontario is a version 4 card from a Canadian province, with CCYYMMDD dates
+var ontario = compliance + "ANSI 636012040101DL00310207" +
+	"DLDCAG\nDCBNONE\nDCDNONE\nDBA20290315\nDCSTREMBLAY\nDACMARIE\nDADCLAIRE\nDBD20240315\n" +
+	"DBB19920315\nDBC2\nDAU165 cm\nDAG100 QUEEN ST W\nDAITORONTO\nDAJON\nDAKM5H 2N2\n" +
+	"DAQT1234-56789-20315\nDCFAB1234567\nDCGCAN\nDDEN\nDDFN\nDDGN\r"
+
+// washington2000 is a card of the first version: one DAA name element and
+// CCYYMMDD dates
+var washington2000 = compliance + "ANSI 6360450101DL00290131" +
+	"DLDAQLINCOLNC123AB\nDAALINCOLN,ABRAHAM,C\nDAG1600 PENNSYLVANIA AVE\nDAIOLYMPIA\nDAJWA\n" +
+	"DAK98501    \nDBB19600212\nDBA20300212\nDBCM\nDAU600\r"
+
+func TestParseVirginiaSample(t *testing.T) {
+	l, err := Parse(virginia)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.IIN != "636000" || l.Version != 9 || l.JurisdictionVersion != 0 || l.DocumentType != SubfileDL {
+		t.Errorf("header = %s v%d/%d %s", l.IIN, l.Version, l.JurisdictionVersion, l.DocumentType)
+	}
+	if l.FamilyName != "SAMPLE" || l.FirstName != "MICHAEL" || l.MiddleNames != "JOHN" {
+		t.Errorf("name = %q %q %q", l.FirstName, l.MiddleNames, l.FamilyName)
+	}
+	if l.LicenceNumber != "T64235789" || l.Jurisdiction != "VA" || l.Country != "USA" || l.Sex != "M" {
+		t.Errorf("licence = %+v", l)
+	}
+	want := Address{Street1: "2300 WEST BROAD STREET", City: "RICHMOND", Jurisdiction: "VA", PostalCode: "23269"}
+	if l.Address != want {
+		t.Errorf("address = %+v", l.Address)
+	}
+	checkDate(t, "birth", l.BirthDate, "1986-06-06")
+	checkDate(t, "issue", l.IssueDate, "2016-06-06")
+	checkDate(t, "expiry", l.ExpiryDate, "2024-12-10")
+
+	if l.Subfiles["ZV"]["ZVA"] != "01" {
+		t.Errorf("jurisdiction subfile = %v", l.Subfiles["ZV"])
+	}
+	if l.Subfiles["DL"]["DCK"] != "123456789" {
+		t.Error("raw elements not kept")
+	}
+}
+
+func TestParseCanadian(t *testing.T) {
+	l, err := Parse(ontario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.FamilyName != "TREMBLAY" || l.FirstName != "MARIE" || l.MiddleNames != "CLAIRE" || l.Sex != "F" {
+		t.Errorf("licence = %+v", l)
+	}
+	if l.Jurisdiction != "ON" || l.Address.PostalCode != "M5H 2N2" || l.JurisdictionVersion != 1 {
+		t.Errorf("jurisdiction = %s %q v%d", l.Jurisdiction, l.Address.PostalCode, l.JurisdictionVersion)
+	}
+	checkDate(t, "birth", l.BirthDate, "1992-03-15")
+	checkDate(t, "expiry", l.ExpiryDate, "2029-03-15")
+}
+
+func TestParseVersionOne(t *testing.T) {
+	l, err := Parse(washington2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Version != 1 || l.IIN != "636045" {
+		t.Errorf("header = %s v%d", l.IIN, l.Version)
+	}
+	if l.FamilyName != "LINCOLN" || l.FirstName != "ABRAHAM" || l.MiddleNames != "C" {
+		t.Errorf("name = %q %q %q", l.FirstName, l.MiddleNames, l.FamilyName)
+	}
+	if l.LicenceNumber != "LINCOLNC123AB" || l.Sex != "M" || l.Address.PostalCode != "98501" {
+		t.Errorf("licence = %+v", l)
+	}
+	checkDate(t, "birth", l.BirthDate, "1960-02-12")
+	checkDate(t, "expiry", l.ExpiryDate, "2030-02-12")
+}
+
+func TestDateOrder(t *testing.T) {
+	tests := []struct {
+		country string
+		version int
+		value   string
+		want    string
+	}{
+		{"USA", 9, "03041990", "1990-03-04"},
+		{"USA", 9, "19900304", "1990-03-04"}, // written the Canadian way
+		{"CAN", 9, "19900304", "1990-03-04"},
+		{"CAN", 9, "03041990", "1990-03-04"}, // written the US way
+		{"", 1, "19900304", "1990-03-04"},
+		{"", 9, "12312020", "2020-12-31"},
+		{"USA", 9, "13131990", ""},
+		{"USA", 9, "0304199", ""},
+	}
+	for _, tt := range tests {
+		l := &Licence{Country: tt.country, Version: tt.version}
+		got := l.date(tt.value)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("date(%s, %s) = %s, want none", tt.country, tt.value, got)
+		case tt.want != "" && (got == nil || got.Format("2006-01-02") != tt.want):
+			t.Errorf("date(%s, %s) = %v, want %s", tt.country, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseWrongOffsets(t *testing.T) {
+	// Scanners drop the control characters, moving every subfile
+	stripped := strings.NewReplacer("\x1e", "", "\r", "\n").Replace(virginia)
+	l, err := Parse(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.LicenceNumber != "T64235789" || l.FamilyName != "SAMPLE" {
+		t.Errorf("licence = %+v", l)
+	}
+
+	bogus := strings.Replace(virginia, "DL00410278", "DL09990278", 1)
+	if l, err := Parse(bogus); err != nil || l.LicenceNumber != "T64235789" {
+		t.Errorf("offset past the end: %v", err)
+	}
+}
+
+func TestParseTruncatedNames(t *testing.T) {
+	payload := strings.Replace(virginia, "DDEN", "DDET", 1)
+	l, err := Parse(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.FamilyNameTruncated || l.FirstNameTruncated {
+		t.Errorf("truncation = %v %v", l.FamilyNameTruncated, l.FirstNameTruncated)
+	}
+}
+
+func TestParseGivenNamesElement(t *testing.T) {
+	payload := compliance + "ANSI 636014030001DL00310051DLDAQD1234567\nDCSPUBLIC\nDCTJOHN,QUINCY\nDBB07041990\r"
+	l, err := Parse(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.FirstName != "JOHN" || l.MiddleNames != "QUINCY" {
+		t.Errorf("given names = %q %q", l.FirstName, l.MiddleNames)
+	}
+}
+
+func TestParseRejectsMalformed(t *testing.T) {
+	header := compliance + "ANSI 636000090001"
+	body := "DLDAQT64235789\nDCSSAMPLE\r"
+	tests := map[string]string{
+		"empty":            "",
+		"not aamva":        "P<UTOERIKSSON<<ANNA<MARIA",
+		"short header":     compliance + "ANSI 6360",
+		"letters in iin":   compliance + "ANSI 63600A090001DL00310025" + body,
+		"missing entries":  header + "DL0031",
+		"zero length":      header + "DL00310000" + body,
+		"one byte length":  header + "DL00310001" + body,
+		"negative length":  header + "DL0031-001" + body,
+		"negative offset":  header + "DL-0310025" + body,
+		"signed offset":    header + "DL+0310025" + body,
+		"no card subfile":  compliance + "ANSI 636000090001ZV00310008ZVZVA01\r",
+		"subfile missing":  header + "DL00310025",
+		"offset past end":  header + "DL99990025",
+		"empty subfile":    header + "DL00310002DL",
+		"designator count": compliance + "ANSI 636000090009DL00310025" + body,
+	}
+	for name, payload := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic %v", name, r)
+				}
+			}()
+			if _, err := Parse(payload); !errors.Is(err, ErrFormat) {
+				t.Errorf("%s: err = %v, want ErrFormat", name, err)
+			}
+		}()
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{virginia, ontario, washington2000, compliance + "ANSI 636000090001DL00310000DL"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, payload string) {
+		Parse(payload)
+	})
+}
+
+func checkDate(t *testing.T, name string, got *time.Time, want string) {
+	t.Helper()
+	if got == nil {
+		t.Errorf("%s date missing, want %s", name, want)
+		return
+	}
+	if got.Format("2006-01-02") != want {
+		t.Errorf("%s date = %s, want %s", name, got.Format("2006-01-02"), want)
+	}
+}
+
+func TestSampleOffsets(t *testing.T) {
+	// The samples' headers point exactly at their card subfile
+	for name, payload := range map[string]string{"virginia": virginia, "ontario": ontario, "washington": washington2000} {
+		designator := payload[strings.Index(payload, "DL0"):]
+		var offset, length int
+		fmt.Sscanf(designator[2:6], "%d", &offset)
+		fmt.Sscanf(designator[6:10], "%d", &length)
+		subfile := payload[offset : offset+length]
+		if !strings.HasPrefix(subfile, "DL") || !strings.HasSuffix(subfile, "\r") {
+			t.Errorf("%s: header designator %q is off", name, designator[:10])
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Package blobstore keeps uploaded files such as identity documents and
+// selfies. Callers store the returned key; the bytes live in whichever
+// backend is configured.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+
+	"kyc-backend/config"
+)
+
+// ErrNotFound is returned by Get when no object exists under the key
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore saves and loads objects by key. Keys use "/" as separator.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Store is the configured backend, set by Setup
+var Store BlobStore = LocalStore{Dir: "uploads"}
+
+// Setup picks the backend from config. Leaving S3_ENDPOINT empty keeps
+// uploads on local disk.
+func Setup() {
+	if config.S3_ENDPOINT == "" {
+		Store = LocalStore{Dir: config.UPLOAD_DIR}
+		log.Printf("Storing uploads in %s", config.UPLOAD_DIR)
+		return
+	}
+
+	Store = S3Store{
+		Endpoint:  config.S3_ENDPOINT,
+		Region:    config.S3_REGION,
+		Bucket:    config.S3_BUCKET,
+		AccessKey: config.S3_ACCESS_KEY,
+		SecretKey: config.S3_SECRET_KEY,
+		PathStyle: config.S3_PATH_STYLE,
+	}
+	log.Printf("Storing uploads in bucket %s at %s", config.S3_BUCKET, config.S3_ENDPOINT)
+}
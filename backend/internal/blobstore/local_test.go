@@ -0,0 +1,78 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store := LocalStore{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "customers/1/selfie.jpg", "image/jpeg", []byte("jpeg")); err != nil {
+		t.Fatal(err)
+	}
+	body, err := store.Get(ctx, "customers/1/selfie.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "jpeg" {
+		t.Errorf("Get returned %q", data)
+	}
+
+	if err := store.Delete(ctx, "customers/1/selfie.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "customers/1/selfie.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v", err)
+	}
+	if err := store.Delete(ctx, "customers/1/selfie.jpg"); err != nil {
+		t.Errorf("deleting a missing object = %v", err)
+	}
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	store := LocalStore{Dir: dir}
+	ctx := context.Background()
+
+	for _, key := range []string{
+		"",
+		"..",
+		"../outside.jpg",
+		"../../etc/passwd",
+		"customers/../../outside.jpg",
+		"/etc/passwd",
+	} {
+		if err := store.Put(ctx, key, "image/jpeg", []byte("x")); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := store.Get(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want an invalid key error", key, err)
+		}
+		if err := store.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "outside.jpg")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("a file was written outside the upload directory")
+	}
+
+	// Dots inside a key are fine as long as it stays within Dir
+	for _, key := range []string{"customers/./1/a.jpg", "customers/1/../2/b.jpg", "..hidden/c.jpg"} {
+		if err := store.Put(ctx, key, "image/jpeg", []byte("x")); err != nil {
+			t.Errorf("Put(%q) = %v", key, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "customers", "2", "b.jpg")); err != nil {
+		t.Errorf("cleaned key not stored under Dir: %v", err)
+	}
+}
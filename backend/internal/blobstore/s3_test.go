@@ -0,0 +1,267 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-central-1"
+)
+
+// TestSignatureAWSExample checks the signing step against the GET object
+// example of the AWS Signature Version 4 documentation
+func TestSignatureAWSExample(t *testing.T) {
+	canonical := strings.Join([]string{
+		"GET",
+		"/test.txt",
+		"",
+		"host:examplebucket.s3.amazonaws.com",
+		"range:bytes=0-9",
+		"x-amz-content-sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"x-amz-date:20130524T000000Z",
+		"",
+		"host;range;x-amz-content-sha256;x-amz-date",
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}, "\n")
+	s := S3Store{Region: "us-east-1", SecretKey: "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"}
+
+	scope, signature := s.signature(canonical, time.Date(2013, 5, 24, 0, 0, 0, 0, time.UTC))
+	if scope != "20130524/us-east-1/s3/aws4_request" {
+		t.Errorf("scope = %s", scope)
+	}
+	if signature != "f0e8bdb87c964420e857bd35b5d6ed310bd44f0170aba48dd91039c6036bdb41" {
+		t.Errorf("signature = %s", signature)
+	}
+}
+
+func TestSignCanonicalRequest(t *testing.T) {
+	s := S3Store{Region: testRegion, AccessKey: testAccessKey, SecretKey: testSecretKey}
+	req, _ := http.NewRequest(http.MethodPut, "http://kyc.s3.example.com/customers/1/id%20front.jpg", nil)
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	s.sign(req, "kyc.s3.example.com", "/customers/1/id%20front.jpg", []byte("data"), now)
+
+	if req.Header.Get("X-Amz-Date") != "20240301T123000Z" {
+		t.Errorf("X-Amz-Date = %s", req.Header.Get("X-Amz-Date"))
+	}
+	if req.Header.Get("X-Amz-Content-Sha256") != sha256Hex([]byte("data")) {
+		t.Error("payload hash header does not match the body")
+	}
+
+	canonical := "PUT\n/customers/1/id%20front.jpg\n\n" +
+		"host:kyc.s3.example.com\n" +
+		"x-amz-content-sha256:" + sha256Hex([]byte("data")) + "\n" +
+		"x-amz-date:20240301T123000Z\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		sha256Hex([]byte("data"))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240301/eu-central-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" +
+		verifySignature(testSecretKey, testRegion, "20240301T123000Z", canonical)
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := map[string]string{
+		"customers/1/selfie.jpg": "customers/1/selfie.jpg",
+		"a b+c=d":                "a%20b%2Bc%3Dd",
+		"unreserved-_.~/ok":      "unreserved-_.~/ok",
+		"ü":                      "%C3%BC",
+		"100%":                   "100%25",
+		"q?x#y":                  "q%3Fx%23y",
+	}
+	for key, want := range tests {
+		if got := escapePath(key); got != want {
+			t.Errorf("escapePath(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// standIn is a minimal S3 that checks every request's signature the way S3
+// does and keeps objects in memory
+type standIn struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	objects map[string][]byte
+	hosts   []string
+	paths   []string
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.hosts = append(s.hosts, r.Host)
+	s.paths = append(s.paths, r.URL.EscapedPath())
+
+	if !s.authorized(r, body) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	// Key the object by host and path so both addressing styles work
+	name := r.Host + r.URL.EscapedPath()
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[name] = body
+	case http.MethodGet:
+		data, ok := s.objects[name]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *standIn) authorized(r *http.Request, body []byte) bool {
+	amzDate := r.Header.Get("X-Amz-Date")
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash != sha256Hex(body) {
+		s.t.Errorf("%s %s: payload hash does not match the body", r.Method, r.URL)
+		return false
+	}
+
+	canonical := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		"host:" + r.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+	want := "AWS4-HMAC-SHA256 Credential=" + testAccessKey + "/" + amzDate[:8] + "/" + testRegion + "/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" +
+		verifySignature(testSecretKey, testRegion, amzDate, canonical)
+	return r.Header.Get("Authorization") == want
+}
+
+// verifySignature is the server side of SigV4, written out step by step
+func verifySignature(secret, region, amzDate, canonical string) string {
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + amzDate[:8] + "/" + region + "/s3/aws4_request\n" + hex.EncodeToString(hash[:])
+
+	key := mac([]byte("AWS4"+secret), amzDate[:8])
+	key = mac(key, region)
+	key = mac(key, "s3")
+	key = mac(key, "aws4_request")
+	return hex.EncodeToString(mac(key, stringToSign))
+}
+
+// startStandIn serves the stand-in and points the S3 client at it whatever
+// host a request names, so virtual-host URLs reach it too
+func startStandIn(t *testing.T) (*standIn, *httptest.Server) {
+	t.Helper()
+	stand := &standIn{t: t, objects: map[string][]byte{}}
+	server := httptest.NewServer(stand)
+	t.Cleanup(server.Close)
+
+	previous := s3Client.Transport
+	s3Client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}
+	t.Cleanup(func() { s3Client.Transport = previous })
+	return stand, server
+}
+
+func TestS3StoreAgainstStandIn(t *testing.T) {
+	stand, server := startStandIn(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name      string
+		pathStyle bool
+		wantHost  string
+		wantPath  string
+	}{
+		{"path style", true, host, "/kyc-uploads/customers/1/id%20front%2B1.jpg"},
+		{"virtual host", false, "kyc-uploads." + host, "/customers/1/id%20front%2B1.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := S3Store{
+				Endpoint:  server.URL,
+				Region:    testRegion,
+				Bucket:    "kyc-uploads",
+				AccessKey: testAccessKey,
+				SecretKey: testSecretKey,
+				PathStyle: tt.pathStyle,
+			}
+			ctx := context.Background()
+			key := "customers/1/id front+1.jpg"
+
+			if err := store.Put(ctx, key, "image/jpeg", []byte("jpeg bytes")); err != nil {
+				t.Fatalf("Put = %v", err)
+			}
+			stand.mutex.Lock()
+			gotHost, gotPath := stand.hosts[len(stand.hosts)-1], stand.paths[len(stand.paths)-1]
+			stand.mutex.Unlock()
+			if gotHost != tt.wantHost || gotPath != tt.wantPath {
+				t.Errorf("request went to %s%s, want %s%s", gotHost, gotPath, tt.wantHost, tt.wantPath)
+			}
+
+			body, err := store.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("Get = %v", err)
+			}
+			data, _ := io.ReadAll(body)
+			body.Close()
+			if string(data) != "jpeg bytes" {
+				t.Errorf("Get returned %q", data)
+			}
+
+			if err := store.Delete(ctx, key); err != nil {
+				t.Fatalf("Delete = %v", err)
+			}
+			if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestS3StoreWrongSecret(t *testing.T) {
+	_, server := startStandIn(t)
+	store := S3Store{
+		Endpoint:  server.URL,
+		Region:    testRegion,
+		Bucket:    "kyc-uploads",
+		AccessKey: testAccessKey,
+		SecretKey: "not the secret",
+		PathStyle: true,
+	}
+
+	err := store.Put(context.Background(), "k", "image/png", []byte("x"))
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Put with the wrong secret = %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package calendar renders RFC 5545 iCalendar invites for KYC meetings and
+// reads holiday calendars.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// iTIP methods used for invites
+const (
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+const icsTime = "20060102T150405Z"
+
+// Invite describes one meeting. UID must stay the same for the lifetime of
+// the meeting and Sequence must grow with every change, so calendar clients
+// update or remove the existing entry instead of adding a new one.
+type Invite struct {
+	UID       string
+	Sequence  int
+	Method    string
+	Start     time.Time
+	Duration  time.Duration
+	Summary   string
+	Link      string
+	AgentName string
+
+	OrganizerEmail string
+	AttendeeName   string
+	AttendeeEmail  string
+}
+
+// ContentType is the MIME type to serve or attach the invite with
+func (inv Invite) ContentType() string {
+	return fmt.Sprintf("text/calendar; method=%s; charset=UTF-8", inv.Method)
+}
+
+// Render produces the .ics file
+func (inv Invite) Render() []byte {
+	status := "CONFIRMED"
+	if inv.Method == MethodCancel {
+		status = "CANCELLED"
+	}
+
+	description := "Join your identity verification call: " + inv.Link
+	if inv.Link == "" {
+		description = "Identity verification call"
+	}
+	if inv.AgentName != "" {
+		description += "\nAgent: " + inv.AgentName
+	}
+	description += "\nPlease have your identity document ready."
+
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(fold(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//webrtc-kyc-proto//KYC Scheduler//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:" + inv.Method)
+	line("BEGIN:VEVENT")
+	line("UID:" + escape(inv.UID))
+	line(fmt.Sprintf("SEQUENCE:%d", inv.Sequence))
+	line("DTSTAMP:" + time.Now().UTC().Format(icsTime))
+	line("DTSTART:" + inv.Start.UTC().Format(icsTime))
+	line("DTEND:" + inv.Start.Add(inv.Duration).UTC().Format(icsTime))
+	line("SUMMARY:" + escape(inv.Summary))
+	line("DESCRIPTION:" + escape(description))
+	if inv.Link != "" {
+		line("LOCATION:" + escape(inv.Link))
+		line("URL:" + inv.Link)
+	}
+	if inv.OrganizerEmail != "" {
+		line("ORGANIZER;CN=KYC Team:mailto:" + inv.OrganizerEmail)
+	}
+	if inv.AttendeeEmail != "" {
+		line(fmt.Sprintf("ATTENDEE;CN=%s;ROLE=REQ-PARTICIPANT;RSVP=FALSE:mailto:%s", paramValue(inv.AttendeeName), inv.AttendeeEmail))
+	}
+	line("STATUS:" + status)
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+
+	return []byte(b.String())
+}
+
+// escape applies TEXT value escaping (RFC 5545 section 3.3.11)
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// paramValue quotes a parameter value when it contains separators
+func paramValue(s string) string {
+	s = strings.NewReplacer(`"`, "'", "\r", "", "\n", " ").Replace(s)
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// fold splits content lines longer than 75 octets (RFC 5545 section 3.1)
+// without breaking multi-byte characters
+func fold(s string) string {
+	if len(s) <= 75 {
+		return s
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
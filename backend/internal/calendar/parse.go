@@ -0,0 +1,162 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned for input that is not an iCalendar file
+var ErrInvalid = errors.New("not an iCalendar file")
+
+// MaxEventDays bounds how many days one event may cover. No public holiday
+// runs longer; a longer closure can be listed as several events.
+const MaxEventDays = 31
+
+// Event is one VEVENT of an imported calendar. End is exclusive, as in
+// RFC 5545; all-day events start and end at midnight UTC.
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+}
+
+// Dates lists the calendar days the event covers, as 2006-01-02, at most
+// MaxEventDays of them. Timed events count the days of their own time zone.
+func (e Event) Dates() []string {
+	end := e.End
+	if !end.After(e.Start) {
+		end = e.Start.Add(time.Nanosecond)
+	}
+
+	var dates []string
+	day := time.Date(e.Start.Year(), e.Start.Month(), e.Start.Day(), 0, 0, 0, 0, e.Start.Location())
+	for ; day.Before(end) && len(dates) < MaxEventDays; day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+	return dates
+}
+
+// Parse reads the events of an iCalendar file such as a public holiday feed.
+// Recurrence rules are not expanded, so feeds must list each occurrence.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrInvalid
+	}
+
+	var events []Event
+	var current *Event
+	var hasEnd bool
+	for _, line := range lines {
+		name, params, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current, hasEnd = &Event{}, false
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil || current.Start.IsZero() {
+				return nil, ErrInvalid
+			}
+			if !hasEnd {
+				// RFC 5545: an all-day event without DTEND lasts one day
+				current.End = current.Start
+				if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			if current.End.Sub(current.Start) > MaxEventDays*24*time.Hour {
+				return nil, ErrInvalid
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "DTSTART":
+			t, allDay, err := parseTime(params, value)
+			if err != nil {
+				return nil, err
+			}
+			current.Start, current.AllDay = t, allDay
+		case name == "DTEND":
+			t, _, err := parseTime(params, value)
+			if err != nil {
+				return nil, err
+			}
+			current.End, hasEnd = t, true
+		}
+	}
+	return events, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty splits `NAME;PARAM=x;PARAM=y:value`
+func splitProperty(line string) (name string, params map[string]string, value string, ok bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+	parts := strings.Split(head, ";")
+	params = make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, found := strings.Cut(p, "="); found {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+func parseTime(params map[string]string, value string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsTime, value)
+		return t, false, err
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,45 @@
+// Package clock reads and writes the weekly opening periods shared by agent
+// working hours and branch business hours.
+package clock
+
+import (
+	"fmt"
+	"time"
+)
+
+// Shift is the API form of a weekly opening period such as
+// models.WorkingHours, with "15:04" times
+type Shift struct {
+	Weekday int    `json:"weekday"` // 0 = Sunday
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+// Minutes returns the shift's start and end in minutes after midnight. It
+// reports false unless the weekday is 0-6 and the shift starts before it
+// ends.
+func (s Shift) Minutes() (int, int, bool) {
+	start, okStart := Parse(s.Start)
+	end, okEnd := Parse(s.End)
+	if s.Weekday < 0 || s.Weekday > 6 || !okStart || !okEnd || end <= start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// Parse reads "HH:MM" as minutes after midnight; "24:00" ends a day
+func Parse(value string) (int, bool) {
+	if value == "24:00" {
+		return 24 * 60, true
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// Format writes minutes after midnight as "HH:MM"
+func Format(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
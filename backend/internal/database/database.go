@@ -1,12 +1,15 @@
 package database
 
 import (
+	"encoding/json"
 	"kyc-backend/config"
 	"kyc-backend/internal/models"
+	"kyc-backend/internal/pii"
 	"log"
 
 	"github.com/glebarez/sqlite" // ✅ Pure Go, CGO-free, GORM-native
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var DB *gorm.DB
@@ -15,7 +18,10 @@ func Connect() {
 	var err error
 
 	// Use glebarez/sqlite — works with CGO_ENABLED=0
-	DB, err = gorm.Open(sqlite.Open(config.DB_FILE+"?_loc=auto"), &gorm.Config{})
+	DB, err = gorm.Open(sqlite.Open(config.DB_FILE+"?_loc=auto"), &gorm.Config{
+		// Report unique index violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -28,9 +34,111 @@ func Connect() {
 }
 
 func migrateModels() error {
-	return DB.AutoMigrate(
+	// Slot reservations used to be unique on start alone, before branches
+	if DB.Migrator().HasIndex(&models.SlotReservation{}, "idx_slot_reservations_start") {
+		if err := DB.Migrator().DropIndex(&models.SlotReservation{}, "idx_slot_reservations_start"); err != nil {
+			return err
+		}
+	}
+
+	if err := DB.AutoMigrate(
 		&models.User{},
 		&models.KYCSession{},
 		&models.Customer{},
-	)
+		&models.AdminEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.OutboxMessage{},
+		&models.Reminder{},
+		&models.StatusTransition{},
+		&models.PIIReveal{},
+		&models.SessionReschedule{},
+		&models.WorkingHours{},
+		&models.Branch{},
+		&models.BusinessHours{},
+		&models.Holiday{},
+		&models.SlotReservation{},
+		&models.KYCVerdict{},
+		&models.DuplicateCandidate{},
+		&models.CustomerMerge{},
+		&models.DocumentExtraction{},
+	); err != nil {
+		return err
+	}
+
+	if err := backfillSlotReservations(); err != nil {
+		return err
+	}
+	return remaskEventLog()
+}
+
+// backfillSlotReservations counts meetings booked before slots were
+// reserved into their slot, so the slot cannot be overbooked and cancelling
+// them gives back their own place. Each session is marked as it is counted,
+// so this runs once per session.
+func backfillSlotReservations() error {
+	var sessions []models.KYCSession
+	if err := DB.Where("status = ? AND holds_slot = ?", models.SessionScheduled, false).Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		var branchID uint
+		if session.BranchID != nil {
+			branchID = *session.BranchID
+		}
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			// Already booked, so the count may go over today's capacity
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.SlotReservation{BranchID: branchID, Start: session.ScheduledAt.Unix()}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.SlotReservation{}).
+				Where("branch_id = ? AND start = ?", branchID, session.ScheduledAt.Unix()).
+				Update("booked", gorm.Expr("booked + 1")).Error; err != nil {
+				return err
+			}
+			return tx.Model(&session).Update("holds_slot", true).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(sessions) > 0 {
+		log.Printf("Counted %d earlier bookings into their slots", len(sessions))
+	}
+	return nil
+}
+
+// remaskEventLog masks national IDs in meeting requests logged before they
+// were masked on publish. Masking is idempotent, so rows already masked are
+// left alone.
+func remaskEventLog() error {
+	var events []models.AdminEvent
+	if err := DB.Where("type = ?", "meeting_request").Find(&events).Error; err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+			continue
+		}
+		id, _ := data["national_id"].(string)
+		masked := pii.MaskNationalID(id)
+		if masked == id {
+			continue
+		}
+
+		data["national_id"] = masked
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		event.Data = string(encoded)
+		if err := DB.Model(&event).Select("data").Updates(&event).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
\ No newline at end of file
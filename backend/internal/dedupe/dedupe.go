@@ -0,0 +1,329 @@
+// Package dedupe finds customers who submitted their profile more than once
+// and merges duplicate records.
+//
+// An exact national ID match, or an email or phone match with a similar
+// name, is certain: the new profile is refused and the customer is pointed to
+// the existing record. Weaker evidence, a shared email or phone under a
+// different name or a similar name with the same date of birth, creates the
+// profile but queues the pair for an admin to merge or dismiss.
+package dedupe
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"kyc-backend/internal/documents"
+	"kyc-backend/internal/kycstate"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/pii"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reasons a pair of customers was matched
+const (
+	ReasonNationalID = "national_id"
+	ReasonEmail      = "email"
+	ReasonPhone      = "phone"
+	ReasonNameDOB    = "name_and_date_of_birth"
+)
+
+// nameThreshold is how similar two names must be to count as the same person
+const nameThreshold = 0.85
+
+var (
+	ErrSameCustomer  = errors.New("cannot merge a customer into itself")
+	ErrAlreadyMerged = errors.New("customer has already been merged")
+	ErrBothOpen      = errors.New("both customers have an open meeting")
+	ErrTargetStatus  = errors.New("target cannot take over an open meeting in its status")
+)
+
+// Match is an existing customer that looks like the same person
+type Match struct {
+	Customer models.Customer
+	Reasons  []string
+	Score    float64 // 0-1
+	Certain  bool
+}
+
+// Find returns the existing customers matching c, most likely first. c only
+// needs its PII fields set; it does not have to be saved yet.
+func Find(tx *gorm.DB, c models.Customer) ([]Match, error) {
+	c.MergedIntoID = nil
+	c.SetIndexes()
+
+	query := tx.Where("merged_into_id IS NULL")
+	if c.ID != 0 {
+		query = query.Where("id <> ?", c.ID)
+	}
+
+	lookups := tx.Where("1 = 0")
+	for column, value := range map[string]string{
+		"national_id_index": c.NationalIDIndex,
+		"email_index":       c.EmailIndex,
+		"phone_index":       c.PhoneIndex,
+		"birth_date_index":  c.BirthDateIndex,
+	} {
+		if value != "" {
+			lookups = lookups.Or(column+" = ?", value)
+		}
+	}
+
+	var candidates []models.Customer
+	if err := query.Where(lookups).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, candidate := range candidates {
+		if m, ok := compare(c, candidate); ok {
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
+
+// compare scores one candidate against c
+func compare(c, candidate models.Customer) (Match, bool) {
+	m := Match{Customer: candidate}
+	same := func(a, b string) bool { return a != "" && a == b }
+
+	if same(c.NationalIDIndex, candidate.NationalIDIndex) {
+		m.Reasons = append(m.Reasons, ReasonNationalID)
+	}
+	if same(c.EmailIndex, candidate.EmailIndex) {
+		m.Reasons = append(m.Reasons, ReasonEmail)
+	}
+	if same(c.PhoneIndex, candidate.PhoneIndex) {
+		m.Reasons = append(m.Reasons, ReasonPhone)
+	}
+
+	similarity := NameSimilarity(c.FullName, candidate.FullName)
+	if same(c.BirthDateIndex, candidate.BirthDateIndex) && similarity >= nameThreshold {
+		m.Reasons = append(m.Reasons, ReasonNameDOB)
+	}
+
+	contact := same(c.EmailIndex, candidate.EmailIndex) || same(c.PhoneIndex, candidate.PhoneIndex)
+	switch {
+	case same(c.NationalIDIndex, candidate.NationalIDIndex):
+		m.Score, m.Certain = 1, true
+	case contact && similarity >= nameThreshold:
+		m.Score, m.Certain = 0.95, true
+	case len(m.Reasons) > 0 && contact:
+		// Families often share an email or phone, so a different name is
+		// only a probable duplicate
+		m.Score = 0.6
+	case len(m.Reasons) > 0:
+		m.Score = 0.9 * similarity
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// Flag queues the probable matches of a new customer for review. Certain
+// matches are expected to have been refused before the customer was created.
+// A pair already in the queue, in either order, is not queued again so a
+// dismissed pair stays dismissed.
+func Flag(tx *gorm.DB, customer models.Customer, matches []Match) error {
+	for _, m := range matches {
+		var existing int64
+		if err := tx.Model(&models.DuplicateCandidate{}).
+			Where("(customer_id = ? AND duplicate_of_id = ?) OR (customer_id = ? AND duplicate_of_id = ?)",
+				customer.ID, m.Customer.ID, m.Customer.ID, customer.ID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			continue
+		}
+
+		candidate := models.DuplicateCandidate{
+			CustomerID:    customer.ID,
+			DuplicateOfID: m.Customer.ID,
+			Reasons:       m.Reasons,
+			Score:         m.Score,
+			Status:        models.DuplicatePending,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&candidate).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge folds source into target: the sessions, verdicts and extracted
+// document data of source move to target, target takes over any documents
+// and details it is missing, and source is kept, marked as merged, for the
+// audit trail. Pending review entries for the pair are closed and target is
+// checked for duplicates again.
+func Merge(tx *gorm.DB, source, target *models.Customer, actorID uint, reason string) (*models.CustomerMerge, error) {
+	if source.ID == target.ID {
+		return nil, ErrSameCustomer
+	}
+	if source.MergedIntoID != nil || target.MergedIntoID != nil {
+		return nil, ErrAlreadyMerged
+	}
+
+	sourceOpen, err := hasOpenSession(tx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	targetOpen, err := hasOpenSession(tx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if sourceOpen && targetOpen {
+		return nil, ErrBothOpen
+	}
+	if sourceOpen && target.KYCStatus != models.KYCScheduled && !kycstate.CanTransitionCustomer(target.KYCStatus, models.KYCScheduled) {
+		return nil, fmt.Errorf("%w: %s", ErrTargetStatus, target.KYCStatus)
+	}
+
+	moved := tx.Model(&models.KYCSession{}).Where("customer_id = ?", source.ID).Update("customer_id", target.ID)
+	if moved.Error != nil {
+		return nil, moved.Error
+	}
+	if err := tx.Model(&models.KYCVerdict{}).Where("customer_id = ?", source.ID).Update("customer_id", target.ID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.DocumentExtraction{}).Where("customer_id = ?", source.ID).Update("customer_id", target.ID).Error; err != nil {
+		return nil, err
+	}
+
+	// Documents and details move only where the target has none. Moved
+	// document keys are cleared on the source so one blob has one owner.
+	// Only the columns changed here are written, so concurrent changes to
+	// either record, such as a status change, are kept.
+	sourceColumns := append([]string{"merged_into_id"}, indexColumns...)
+	var targetColumns []string
+	var docs []string
+	for _, kind := range documents.Kinds {
+		from, to := documents.Field(source, kind), documents.Field(target, kind)
+		if *to == "" && *from != "" {
+			*to, *from = *from, ""
+			docs = append(docs, kind)
+			sourceColumns = append(sourceColumns, documents.Column(kind))
+			targetColumns = append(targetColumns, documents.Column(kind))
+		}
+	}
+	fill := func(column string, field *string, value string) {
+		if *field == "" && value != "" {
+			*field = value
+			targetColumns = append(targetColumns, column)
+		}
+	}
+	fill("phone", &target.Phone, source.Phone)
+	fill("national_id", &target.NationalID, source.NationalID)
+	fill("preferred_language", &target.PreferredLanguage, source.PreferredLanguage)
+	fill("nationality", &target.Nationality, source.Nationality)
+	fill("product", &target.Product, source.Product)
+	fill("time_zone", &target.TimeZone, source.TimeZone)
+	if target.DateOfBirth == nil && source.DateOfBirth != nil {
+		target.DateOfBirth = source.DateOfBirth
+		targetColumns = append(targetColumns, "date_of_birth")
+	}
+
+	actor := kycstate.AgentActor(actorID)
+	if sourceOpen {
+		if err := kycstate.TransitionCustomer(tx, target, models.KYCScheduled, actor, fmt.Sprintf("merged customer %d", source.ID)); err != nil {
+			return nil, err
+		}
+	}
+
+	source.MergedIntoID = &target.ID
+	if err := tx.Model(source).Select(sourceColumns).Updates(source).Error; err != nil {
+		return nil, err
+	}
+	if len(targetColumns) > 0 {
+		targetColumns = append(targetColumns, indexColumns...)
+		if err := tx.Model(target).Select(targetColumns).Updates(target).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.DuplicateCandidate{}).
+		Where("status = ? AND (customer_id = ? OR duplicate_of_id = ?)", models.DuplicatePending, source.ID, source.ID).
+		Updates(map[string]any{
+			"status":      models.DuplicateMerged,
+			"reviewed_by": actorID,
+			"reviewed_at": now,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	merge := models.CustomerMerge{
+		SourceID:  source.ID,
+		TargetID:  target.ID,
+		ActorID:   actorID,
+		Reason:    reason,
+		Sessions:  int(moved.RowsAffected),
+		Documents: docs,
+	}
+	if err := tx.Create(&merge).Error; err != nil {
+		return nil, err
+	}
+
+	// The merged record may have linked target to customers neither matched
+	// on its own
+	matches, err := Find(tx, *target)
+	if err != nil {
+		return nil, err
+	}
+	if err := Flag(tx, *target, matches); err != nil {
+		return nil, err
+	}
+	return &merge, nil
+}
+
+// indexColumns are the blind indexes Customer.BeforeSave recomputes
+var indexColumns = []string{"national_id_index", "email_index", "phone_index", "birth_date_index"}
+
+// NameSimilarity compares two names after normalizing them, from 0 for
+// nothing in common to 1 for the same name in any word order
+func NameSimilarity(a, b string) float64 {
+	a, b = pii.NormalizeName(a), pii.NormalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	distance := levenshtein(ra, rb)
+	return 1 - float64(distance)/float64(max(len(ra), len(rb)))
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func hasOpenSession(tx *gorm.DB, customerID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.KYCSession{}).
+		Where("customer_id = ? AND status IN ?", customerID, []string{models.SessionScheduled, models.SessionOngoing}).
+		Count(&count).Error
+	return count > 0, err
+}
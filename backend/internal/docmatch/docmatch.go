@@ -0,0 +1,163 @@
+// Package docmatch compares identity data read from a document, such as a
+// passport MRZ or a licence barcode, with what the customer put in their
+// profile, so the agent sees at a glance which fields disagree.
+package docmatch
+
+import (
+	"strings"
+	"time"
+
+	"kyc-backend/internal/dedupe"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/pii"
+)
+
+// nameThreshold is how similar the names must be to count as a match
+const nameThreshold = 0.85
+
+// Extracted is the identity data read from a document. Empty fields were not
+// on the document.
+type Extracted struct {
+	Surname        string
+	GivenNames     string
+	BirthDate      *time.Time
+	Nationality    string // any country code the document uses
+	DocumentNumber string
+	PersonalNumber string // national ID printed on the document, if any
+	ExpiryDate     *time.Time
+}
+
+// Compare checks the extracted data against the customer's profile. The
+// profile's national ID is masked in the result.
+func Compare(e Extracted, c models.Customer, now time.Time) []models.FieldCheck {
+	var checks []models.FieldCheck
+
+	fullName := strings.TrimSpace(e.GivenNames + " " + e.Surname)
+	checks = append(checks, compare("full_name", fullName, c.FullName, namesMatch))
+
+	var docBirth, profileBirth string
+	if e.BirthDate != nil {
+		docBirth = e.BirthDate.Format("2006-01-02")
+	}
+	if c.DateOfBirth != nil {
+		profileBirth = c.DateOfBirth.Format("2006-01-02")
+	}
+	checks = append(checks, compare("date_of_birth", docBirth, profileBirth, equal))
+
+	nationality, _ := Alpha2(e.Nationality)
+	check := compare("nationality", nationality, c.Nationality, equal)
+	check.Document = e.Nationality
+	checks = append(checks, check)
+
+	checks = append(checks, nationalID(e, c))
+
+	if e.ExpiryDate != nil {
+		status := models.CheckValid
+		if e.ExpiryDate.Before(now) {
+			status = models.CheckExpired
+		}
+		checks = append(checks, models.FieldCheck{
+			Field:    "expiry_date",
+			Status:   status,
+			Document: e.ExpiryDate.Format("2006-01-02"),
+		})
+	}
+	return checks
+}
+
+// Mismatches counts the checks an agent must look at
+func Mismatches(checks []models.FieldCheck) int {
+	n := 0
+	for _, check := range checks {
+		if check.Status == models.CheckMismatch || check.Status == models.CheckExpired {
+			n++
+		}
+	}
+	return n
+}
+
+// nationalID matches the profile's national ID against the personal number
+// on the document, or the document number for ID cards numbered by it.
+// Passports usually carry neither, so no match there is only unverified.
+// Both sides are masked, like the national ID everywhere else.
+func nationalID(e Extracted, c models.Customer) models.FieldCheck {
+	check := models.FieldCheck{
+		Field:    "national_id",
+		Status:   models.CheckUnverified,
+		Document: pii.MaskNationalID(e.PersonalNumber),
+		Profile:  pii.MaskNationalID(c.NationalID),
+	}
+	if c.NationalID == "" {
+		return check
+	}
+
+	profile := canonicalID(c.NationalID)
+	switch {
+	case e.PersonalNumber != "" && canonicalID(e.PersonalNumber) == profile:
+		check.Status = models.CheckMatch
+	case e.DocumentNumber != "" && canonicalID(e.DocumentNumber) == profile:
+		check.Status = models.CheckMatch
+		check.Document = pii.MaskNationalID(e.DocumentNumber)
+	case e.PersonalNumber != "":
+		check.Status = models.CheckMismatch
+	}
+	return check
+}
+
+func compare(field, document, profile string, same func(a, b string) bool) models.FieldCheck {
+	check := models.FieldCheck{Field: field, Document: document, Profile: profile}
+	switch {
+	case document == "" || profile == "":
+		check.Status = models.CheckUnverified
+	case same(document, profile):
+		check.Status = models.CheckMatch
+	default:
+		check.Status = models.CheckMismatch
+	}
+	return check
+}
+
+func equal(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// namesMatch accepts similar spellings, and a profile name that leaves out
+// middle names the document has or the other way round
+func namesMatch(document, profile string) bool {
+	if dedupe.NameSimilarity(document, profile) >= nameThreshold {
+		return true
+	}
+	docWords := strings.Fields(pii.NormalizeName(document))
+	profileWords := strings.Fields(pii.NormalizeName(profile))
+	return len(docWords) > 0 && len(profileWords) > 0 &&
+		(subset(profileWords, docWords) || subset(docWords, profileWords))
+}
+
+// subset reports whether every word of a is in b, needing at least two
+// words so a lone surname is not enough
+func subset(a, b []string) bool {
+	if len(a) < 2 {
+		return false
+	}
+	seen := make(map[string]bool, len(b))
+	for _, w := range b {
+		seen[w] = true
+	}
+	for _, w := range a {
+		if !seen[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalID drops case and separators, as the national ID blind index does
+func canonicalID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '/', '.', '<':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(id))
+}
@@ -0,0 +1,52 @@
+package docmatch
+
+import "testing"
+
+func TestNamesMatch(t *testing.T) {
+	tests := []struct {
+		document, profile string
+		want              bool
+	}{
+		{"ANNA MARIA ERIKSSON", "Anna Maria Eriksson", true},
+		{"ERIKSSON ANNA MARIA", "Anna Maria Eriksson", true},
+		{"ANNA ERIKSON", "Anna Eriksson", true},
+		{"ANNA MARIA ERIKSSON", "Anna Eriksson", true},
+		{"RAM THAPA", "Ram Bahadur Thapa", true},
+		{"PETER JOHN STEVENSON", "Peter Jon Stevenson", true},
+		{"ERIKSSON", "Anna Eriksson", false},
+		{"ANNA ERIKSSON", "Maria Eriksson", false},
+		{"RAM THAPA", "Sita Thapa", false},
+		{"", "Anna Eriksson", false},
+		{"ANNA ERIKSSON", "", false},
+	}
+	for _, tt := range tests {
+		if got := namesMatch(tt.document, tt.profile); got != tt.want {
+			t.Errorf("namesMatch(%q, %q) = %v, want %v", tt.document, tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestAlpha2(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+		ok   bool
+	}{
+		{"NPL", "NP", true},
+		{"npl ", "NP", true},
+		{"GBR", "GB", true},
+		{"USA", "US", true},
+		{"D", "DE", true},
+		{"GBN", "GB", true},
+		{"RKS", "XK", true},
+		{"NP", "NP", true},
+		{"UTO", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Alpha2(tt.code)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Alpha2(%q) = %q, %v, want %q, %v", tt.code, got, ok, tt.want, tt.ok)
+		}
+	}
+}
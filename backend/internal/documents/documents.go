@@ -0,0 +1,108 @@
+// Package documents validates the identity document and selfie images
+// customers upload and maps them to the customer fields that reference them.
+package documents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"kyc-backend/internal/models"
+)
+
+// Kinds of upload a customer can make
+const (
+	KindIDFront = "id_front"
+	KindIDBack  = "id_back"
+	KindSelfie  = "selfie"
+)
+
+// Kinds lists every upload kind
+var Kinds = []string{KindIDFront, KindIDBack, KindSelfie}
+
+// Image limits. The minimum keeps the document text readable for the agent;
+// the maximum guards against decompression bombs.
+const (
+	minShortSide = 480
+	maxLongSide  = 8000
+)
+
+// ErrInvalid wraps every reason an upload is rejected
+var ErrInvalid = errors.New("invalid upload")
+
+// allowedTypes maps sniffed content types to the file extension stored
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// Valid reports whether kind is a known upload kind
+func Valid(kind string) bool {
+	return kind == KindIDFront || kind == KindIDBack || kind == KindSelfie
+}
+
+// Field returns a pointer to the customer field holding the key for kind
+func Field(customer *models.Customer, kind string) *string {
+	switch kind {
+	case KindIDFront:
+		return &customer.IDDocumentURL
+	case KindIDBack:
+		return &customer.IDDocumentBackURL
+	case KindSelfie:
+		return &customer.SelfieURL
+	}
+	return nil
+}
+
+// Column is the database column behind Field
+func Column(kind string) string {
+	switch kind {
+	case KindIDFront:
+		return "id_document_url"
+	case KindIDBack:
+		return "id_document_back_url"
+	case KindSelfie:
+		return "selfie_url"
+	}
+	return ""
+}
+
+// Inspect sniffs the content type from the bytes themselves, ignoring what
+// the client claimed, and checks the image dimensions. It returns the
+// content type and the extension to store the file under.
+func Inspect(data []byte, maxBytes int64) (string, string, error) {
+	if len(data) == 0 {
+		return "", "", fmt.Errorf("%w: file is empty", ErrInvalid)
+	}
+	if int64(len(data)) > maxBytes {
+		return "", "", fmt.Errorf("%w: file is larger than %d MB", ErrInvalid, maxBytes>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s is not a supported image type, use JPEG or PNG", ErrInvalid, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: image cannot be read", ErrInvalid)
+	}
+
+	short, long := cfg.Width, cfg.Height
+	if short > long {
+		short, long = long, short
+	}
+	if short < minShortSide {
+		return "", "", fmt.Errorf("%w: image is %dx%d, the shorter side must be at least %d pixels", ErrInvalid, cfg.Width, cfg.Height, minShortSide)
+	}
+	if long > maxLongSide {
+		return "", "", fmt.Errorf("%w: image is %dx%d, the longer side must be at most %d pixels", ErrInvalid, cfg.Width, cfg.Height, maxLongSide)
+	}
+
+	return contentType, ext, nil
+}
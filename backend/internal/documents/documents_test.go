@@ -0,0 +1,99 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// bombPNG is a tiny PNG whose header claims huge dimensions
+func bombPNG(t *testing.T, w, h uint32) []byte {
+	data := encodePNG(t, 1, 1)
+	// IHDR data follows the 8-byte signature and the chunk's length and type
+	binary.BigEndian.PutUint32(data[16:20], w)
+	binary.BigEndian.PutUint32(data[20:24], h)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestInspectAccepts(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		wantType string
+		wantExt  string
+	}{
+		{"jpeg", encodeJPEG(t, 640, 480), "image/jpeg", ".jpg"},
+		{"png", encodePNG(t, 480, 640), "image/png", ".png"},
+		{"largest png", encodePNG(t, 8000, 480), "image/png", ".png"},
+	}
+	for _, tt := range tests {
+		contentType, ext, err := Inspect(tt.data, 10<<20)
+		if err != nil || contentType != tt.wantType || ext != tt.wantExt {
+			t.Errorf("%s: Inspect = %s, %s, %v", tt.name, contentType, ext, err)
+		}
+	}
+}
+
+func TestInspectRejects(t *testing.T) {
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	pdf := []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// A PNG signature on something that is not a PNG
+	fakePNG := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		maxBytes int64
+	}{
+		{"empty", nil, 10 << 20},
+		{"too large", encodePNG(t, 640, 480), 100},
+		{"gif", gif, 10 << 20},
+		{"pdf", pdf, 10 << 20},
+		{"html", []byte("<html><script>alert(1)</script></html>"), 10 << 20},
+		{"corrupt png", fakePNG, 10 << 20},
+		{"too small", encodeJPEG(t, 640, 479), 10 << 20},
+		{"too small portrait", encodePNG(t, 300, 900), 10 << 20},
+		{"too long", encodePNG(t, 8001, 480), 10 << 20},
+		{"decompression bomb", bombPNG(t, 100000, 100000), 10 << 20},
+	}
+	for _, tt := range tests {
+		if _, _, err := Inspect(tt.data, tt.maxBytes); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: Inspect = %v, want ErrInvalid", tt.name, err)
+		}
+	}
+}
+
+func TestKinds(t *testing.T) {
+	for _, kind := range Kinds {
+		if !Valid(kind) || Column(kind) == "" {
+			t.Errorf("kind %s is not fully mapped", kind)
+		}
+	}
+	if Valid("passport") || Column("passport") != "" {
+		t.Error("unknown kind accepted")
+	}
+}
@@ -0,0 +1,242 @@
+// Package fieldcrypt encrypts individual database columns holding customer
+// PII. Each value gets its own AES-256-GCM data key, which is wrapped with a
+// versioned key-encryption key from the KeyProvider (envelope encryption).
+// Tag a model field with `gorm:"serializer:encrypted"` to use it.
+//
+// Stored values look like enc.<key version>.<wrapped data key>.<ciphertext>.
+// Values without that prefix are legacy plaintext and are returned as-is
+// until Rekey encrypts them.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"kyc-backend/config"
+)
+
+const prefix = "enc."
+
+// ErrNotConfigured is returned when encrypting before Setup succeeded
+var ErrNotConfigured = errors.New("field encryption keys are not configured")
+
+var (
+	provider KeyProvider
+	indexKey []byte
+	mutex    = &sync.RWMutex{}
+)
+
+// Setup loads the keys from config. Missing keys are fatal unless
+// ALLOW_INSECURE_DEV_KEYS is set in development, where a fixed key from the
+// source is used so the app runs without setup.
+func Setup() {
+	if config.ENCRYPTION_KEYS == "" || config.BLIND_INDEX_KEY == "" {
+		if !config.ALLOW_INSECURE_DEV_KEYS || config.ENV != "dev" {
+			log.Fatal("ENCRYPTION_KEYS and BLIND_INDEX_KEY must be set")
+		}
+		log.Println("ENCRYPTION_KEYS not found, using insecure development keys")
+		devKey := sha256.Sum256([]byte("kyc-backend development key"))
+		devIndex := sha256.Sum256([]byte("kyc-backend development index key"))
+		Configure(LocalKeyProvider{
+			Keys:           map[string][]byte{"dev": devKey[:]},
+			CurrentVersion: "dev",
+		}, devIndex[:])
+		return
+	}
+
+	keys, err := ParseKeys(config.ENCRYPTION_KEYS, config.ENCRYPTION_KEY_VERSION)
+	if err != nil {
+		log.Fatalf("Invalid ENCRYPTION_KEYS: %v", err)
+	}
+	blind, err := base64.StdEncoding.DecodeString(config.BLIND_INDEX_KEY)
+	if err != nil || len(blind) < 32 {
+		log.Fatal("BLIND_INDEX_KEY must be at least 32 bytes, base64 encoded")
+	}
+	Configure(keys, blind)
+}
+
+// Configure installs the key provider and the blind index key
+func Configure(keys KeyProvider, blindIndexKey []byte) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	provider = keys
+	indexKey = blindIndexKey
+}
+
+// Encrypt seals plaintext under a fresh data key wrapped with the current
+// key version
+func Encrypt(plaintext []byte) (string, error) {
+	mutex.RLock()
+	keys := provider
+	mutex.RUnlock()
+	if keys == nil {
+		return "", ErrNotConfigured
+	}
+
+	version, kek := keys.Current()
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(kek, dek)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dek, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + version + "." +
+		base64.RawURLEncoding.EncodeToString(wrapped) + "." +
+		base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with whichever key version
+// wrapped it
+func Decrypt(value string) ([]byte, error) {
+	mutex.RLock()
+	keys := provider
+	mutex.RUnlock()
+	if keys == nil {
+		return nil, ErrNotConfigured
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ".")
+	if !IsEncrypted(value) || len(parts) != 3 {
+		return nil, errors.New("malformed encrypted value")
+	}
+
+	kek, err := keys.Key(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed data key: %w", err)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ciphertext: %w", err)
+	}
+
+	dek, err := open(kek, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return open(dek, sealed)
+}
+
+// IsEncrypted reports whether a stored value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyVersion returns the key version of an encrypted value, or "" for
+// plaintext
+func KeyVersion(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	version, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ".")
+	return version
+}
+
+// NeedsRekey reports whether a stored value is plaintext or wrapped with a
+// key other than the current one
+func NeedsRekey(value string) bool {
+	if value == "" {
+		return false
+	}
+	mutex.RLock()
+	keys := provider
+	mutex.RUnlock()
+	if keys == nil {
+		return false
+	}
+	current, _ := keys.Current()
+	return KeyVersion(value) != current
+}
+
+// BlindIndex returns a keyed hash of the normalized value, so exact-match
+// lookups work without decrypting every row. Empty values index to "".
+func BlindIndex(value string) string {
+	return BlindIndexNormalized(normalize(value))
+}
+
+// BlindIndexNormalized is BlindIndex for values the caller has already put in
+// canonical form, such as lower-cased emails
+func BlindIndexNormalized(normalized string) string {
+	if normalized == "" {
+		return ""
+	}
+
+	mutex.RLock()
+	key := indexKey
+	mutex.RUnlock()
+	if key == nil {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalize drops case, spaces and separators so "12-345 67" and "1234567"
+// index the same
+func normalize(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(value) {
+		if r == ' ' || r == '-' || r == '/' || r == '.' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
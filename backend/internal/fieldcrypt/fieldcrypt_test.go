@@ -0,0 +1,260 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func useKeys(t *testing.T, current string, keys map[string][]byte) {
+	t.Helper()
+	Configure(LocalKeyProvider{Keys: keys, CurrentVersion: current}, testKey(9))
+	t.Cleanup(func() { Configure(nil, nil) })
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	useKeys(t, "v1", map[string][]byte{"v1": testKey(1)})
+
+	sealed, err := Encrypt([]byte("9841234567"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) || KeyVersion(sealed) != "v1" {
+		t.Fatalf("unexpected envelope %q", sealed)
+	}
+	if strings.Contains(sealed, "9841234567") {
+		t.Fatal("plaintext visible in envelope")
+	}
+
+	again, _ := Encrypt([]byte("9841234567"))
+	if again == sealed {
+		t.Fatal("two encryptions of the same value are identical")
+	}
+
+	plaintext, err := Decrypt(sealed)
+	if err != nil || string(plaintext) != "9841234567" {
+		t.Fatalf("Decrypt = %q, %v", plaintext, err)
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	useKeys(t, "v1", map[string][]byte{"v1": testKey(1)})
+
+	sealed, _ := Encrypt([]byte("secret"))
+	parts := strings.Split(sealed, ".")
+
+	ciphertext, _ := base64.RawURLEncoding.DecodeString(parts[3])
+	ciphertext[len(ciphertext)-1] ^= 1
+	parts[3] = base64.RawURLEncoding.EncodeToString(ciphertext)
+	if _, err := Decrypt(strings.Join(parts, ".")); err == nil {
+		t.Fatal("tampered ciphertext decrypted")
+	}
+
+	wrapped, _ := base64.RawURLEncoding.DecodeString(strings.Split(sealed, ".")[2])
+	wrapped[0] ^= 1
+	parts = strings.Split(sealed, ".")
+	parts[2] = base64.RawURLEncoding.EncodeToString(wrapped)
+	if _, err := Decrypt(strings.Join(parts, ".")); err == nil {
+		t.Fatal("tampered data key unwrapped")
+	}
+
+	for _, value := range []string{"plain", "enc.v1.abc", "enc.v1.!!.!!"} {
+		if _, err := Decrypt(value); err == nil {
+			t.Errorf("Decrypt(%q) succeeded", value)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	useKeys(t, "v1", map[string][]byte{"v1": testKey(1)})
+	old, _ := Encrypt([]byte("old"))
+
+	useKeys(t, "v2", map[string][]byte{"v1": testKey(1), "v2": testKey(2)})
+	if plaintext, err := Decrypt(old); err != nil || string(plaintext) != "old" {
+		t.Fatalf("old value after rotation = %q, %v", plaintext, err)
+	}
+	if !NeedsRekey(old) {
+		t.Error("value under the old key does not need a rekey")
+	}
+	current, _ := Encrypt([]byte("new"))
+	if NeedsRekey(current) || KeyVersion(current) != "v2" {
+		t.Errorf("new value %q not under the current key", current)
+	}
+	if !NeedsRekey("legacy plaintext") || NeedsRekey("") {
+		t.Error("NeedsRekey wrong for plaintext or empty values")
+	}
+
+	useKeys(t, "v2", map[string][]byte{"v2": testKey(2)})
+	if _, err := Decrypt(old); err == nil {
+		t.Error("value decrypted after its key was removed")
+	}
+}
+
+func TestEncryptNotConfigured(t *testing.T) {
+	Configure(nil, nil)
+	if _, err := Encrypt([]byte("x")); err != ErrNotConfigured {
+		t.Fatalf("Encrypt without keys = %v", err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+	short := base64.StdEncoding.EncodeToString([]byte("short"))
+
+	tests := []struct {
+		spec, current string
+		wantCurrent   string
+		wantErr       bool
+	}{
+		{spec: "v1:" + k1, wantCurrent: "v1"},
+		{spec: "v1:" + k1 + ", v2:" + k2, wantCurrent: "v2"},
+		{spec: "v1:" + k1 + ",v2:" + k2, current: "v1", wantCurrent: "v1"},
+		{spec: "v1:" + k1, current: "v3", wantErr: true},
+		{spec: "", wantErr: true},
+		{spec: k1, wantErr: true},
+		{spec: "v.1:" + k1, wantErr: true},
+		{spec: "v1:" + short, wantErr: true},
+		{spec: "v1:not base64!", wantErr: true},
+	}
+	for _, tt := range tests {
+		keys, err := ParseKeys(tt.spec, tt.current)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseKeys(%q, %q) succeeded", tt.spec, tt.current)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseKeys(%q, %q) = %v", tt.spec, tt.current, err)
+			continue
+		}
+		if version, key := keys.Current(); version != tt.wantCurrent || len(key) != 32 {
+			t.Errorf("ParseKeys(%q, %q) current = %s", tt.spec, tt.current, version)
+		}
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	useKeys(t, "v1", map[string][]byte{"v1": testKey(1)})
+
+	if BlindIndex("") != "" {
+		t.Error("empty value has an index")
+	}
+	a := BlindIndex("ab-123 45")
+	if a == "" || a != BlindIndex("AB12345") || a != BlindIndex("ab/123.45") {
+		t.Error("separators or case change the index")
+	}
+	if a == BlindIndex("AB12346") {
+		t.Error("different values share an index")
+	}
+	if BlindIndexNormalized("ab12345") == a {
+		t.Error("BlindIndexNormalized normalized its input")
+	}
+
+	Configure(LocalKeyProvider{Keys: map[string][]byte{"v1": testKey(1)}, CurrentVersion: "v1"}, testKey(8))
+	if BlindIndex("AB12345") == a {
+		t.Error("index does not depend on the key")
+	}
+}
+
+type record struct {
+	ID         uint
+	Name       string            `gorm:"serializer:encrypted"`
+	BirthDate  *time.Time        `gorm:"serializer:encrypted"`
+	Tags       []string          `gorm:"serializer:encrypted"`
+	Attributes map[string]string `gorm:"serializer:encrypted"`
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&record{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSerializerRoundTrip(t *testing.T) {
+	useKeys(t, "v1", map[string][]byte{"v1": testKey(1)})
+	db := openDB(t)
+
+	birth := time.Date(1990, 7, 4, 0, 0, 0, 0, time.UTC)
+	in := record{Name: "Ram Bahadur", BirthDate: &birth, Tags: []string{"a", "b"}, Attributes: map[string]string{"k": "v"}}
+	if err := db.Create(&in).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var stored struct{ Name, BirthDate, Tags string }
+	db.Table("records").Select("name", "birth_date", "tags").Where("id = ?", in.ID).Scan(&stored)
+	for _, value := range []string{stored.Name, stored.BirthDate, stored.Tags} {
+		if !IsEncrypted(value) {
+			t.Fatalf("column stored as %q", value)
+		}
+	}
+
+	var out record
+	if err := db.First(&out, in.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != in.Name || !out.BirthDate.Equal(birth) || len(out.Tags) != 2 || out.Attributes["k"] != "v" {
+		t.Fatalf("round trip = %+v", out)
+	}
+
+	empty := record{}
+	db.Create(&empty)
+	var raw struct{ Name string }
+	db.Table("records").Select("name").Where("id = ?", empty.ID).Scan(&raw)
+	if raw.Name != "" {
+		t.Errorf("empty string stored as %q", raw.Name)
+	}
+	var back record
+	db.First(&back, empty.ID)
+	if back.Name != "" || back.BirthDate != nil {
+		t.Errorf("empty record read back as %+v", back)
+	}
+}
+
+func TestSerializerLegacyScan(t *testing.T) {
+	useKeys(t, "v1", map[string][]byte{"v1": testKey(1)})
+	db := openDB(t)
+
+	err := db.Exec(`INSERT INTO records (id, name, birth_date, tags, attributes) VALUES (1, ?, ?, ?, ?)`,
+		"Sita Sharma", "1985-02-03 00:00:00+00:00", `["x"]`, `{"k":"v"}`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out record
+	if err := db.First(&out, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Sita Sharma" || out.BirthDate == nil || out.BirthDate.Format("2006-01-02") != "1985-02-03" {
+		t.Fatalf("legacy row = %+v", out)
+	}
+	if len(out.Tags) != 1 || out.Tags[0] != "x" || out.Attributes["k"] != "v" {
+		t.Fatalf("legacy JSON columns = %+v", out)
+	}
+
+	// Saving the legacy row encrypts it
+	if err := db.Save(&out).Error; err != nil {
+		t.Fatal(err)
+	}
+	var stored struct{ Name string }
+	db.Table("records").Select("name").Where("id = ?", 1).Scan(&stored)
+	if !IsEncrypted(stored.Name) {
+		t.Errorf("legacy row still plaintext after save: %q", stored.Name)
+	}
+}
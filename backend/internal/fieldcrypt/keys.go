@@ -0,0 +1,69 @@
+package fieldcrypt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// KeyProvider hands out the key-encryption keys. Every stored value records
+// the version of the key that wrapped it, so older versions must stay
+// available until Rekey has moved all rows to the current one.
+type KeyProvider interface {
+	// Current returns the version and key used for new writes
+	Current() (string, []byte)
+	// Key returns the key of an earlier or current version
+	Key(version string) ([]byte, error)
+}
+
+// LocalKeyProvider keeps the keys in process memory, loaded from config
+type LocalKeyProvider struct {
+	Keys           map[string][]byte
+	CurrentVersion string
+}
+
+func (p LocalKeyProvider) Current() (string, []byte) {
+	return p.CurrentVersion, p.Keys[p.CurrentVersion]
+}
+
+func (p LocalKeyProvider) Key(version string) ([]byte, error) {
+	key, ok := p.Keys[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key version %q", version)
+	}
+	return key, nil
+}
+
+// ParseKeys reads a "version:base64key,version:base64key" list. The current
+// version defaults to the last one listed.
+func ParseKeys(spec, current string) (LocalKeyProvider, error) {
+	provider := LocalKeyProvider{Keys: make(map[string][]byte)}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		version, encoded, ok := strings.Cut(part, ":")
+		if !ok || version == "" || strings.Contains(version, ".") {
+			return provider, fmt.Errorf("invalid encryption key entry, expected version:base64key")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return provider, fmt.Errorf("encryption key %s must be 32 bytes, base64 encoded", version)
+		}
+		provider.Keys[version] = key
+		provider.CurrentVersion = version
+	}
+
+	if len(provider.Keys) == 0 {
+		return provider, fmt.Errorf("no encryption keys given")
+	}
+	if current != "" {
+		if _, ok := provider.Keys[current]; !ok {
+			return provider, fmt.Errorf("current encryption key %s is not in the key list", current)
+		}
+		provider.CurrentVersion = current
+	}
+	return provider, nil
+}
@@ -0,0 +1,103 @@
+package fieldcrypt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Serializer encrypts a field on write and decrypts it on read. The value is
+// JSON encoded before encryption, so any field type works. Empty strings and
+// nil pointers are stored as-is so "not given" stays distinguishable.
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+		return field.Set(ctx, dst, reflect.Zero(field.FieldType).Interface())
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	case time.Time:
+		// Legacy plaintext datetime column
+		return field.Set(ctx, dst, v)
+	default:
+		return fmt.Errorf("unsupported value %T for encrypted field %s", dbValue, field.Name)
+	}
+
+	if !IsEncrypted(stored) {
+		return scanLegacy(ctx, field, dst, stored)
+	}
+
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return fmt.Errorf("decrypting %s: %w", field.Name, err)
+	}
+
+	value := reflect.New(field.FieldType)
+	if err := json.Unmarshal(plaintext, value.Interface()); err != nil {
+		return fmt.Errorf("decoding %s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, value.Elem().Interface())
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(fieldValue)
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		return nil, nil
+	}
+	if rv.Kind() == reflect.String && rv.Len() == 0 {
+		return "", nil
+	}
+
+	plaintext, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(plaintext)
+}
+
+// legacyTimeLayouts are the formats the SQLite driver wrote datetimes in
+var legacyTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// scanLegacy reads a value written before the column was encrypted
+func scanLegacy(ctx context.Context, field *schema.Field, dst reflect.Value, stored string) error {
+	if stored == "" {
+		return field.Set(ctx, dst, reflect.Zero(field.FieldType).Interface())
+	}
+
+	switch field.FieldType {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(&time.Time{}):
+		for _, layout := range legacyTimeLayouts {
+			if t, err := time.Parse(layout, stored); err == nil {
+				return field.Set(ctx, dst, t)
+			}
+		}
+		return fmt.Errorf("unreadable legacy time in %s", field.Name)
+	}
+	if field.FieldType.Kind() != reflect.String {
+		// Written by the json serializer before the column was encrypted
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal([]byte(stored), value.Interface()); err != nil {
+			return fmt.Errorf("decoding legacy %s: %w", field.Name, err)
+		}
+		return field.Set(ctx, dst, value.Elem().Interface())
+	}
+	return field.Set(ctx, dst, stored)
+}
@@ -0,0 +1,137 @@
+// Package jointoken issues and checks the signed tokens in customer meeting
+// links. A token names its meeting, expires a while after the meeting time
+// and may only be used to join a limited number of times.
+//
+// Tokens are derived from the session, so the same session always yields the
+// same token and reminders or invites can rebuild the link. Replacing the
+// session's AccessToken nonce revokes every outstanding token.
+package jointoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalid covers malformed tokens, bad signatures and tokens for
+	// another meeting or a revoked link
+	ErrInvalid = errors.New("invalid meeting token")
+	ErrExpired = errors.New("meeting link has expired")
+	ErrUsedUp  = errors.New("meeting link has been used too many times")
+)
+
+// Claims are the signed contents of a token
+type Claims struct {
+	MeetingID string `json:"mid"`
+	Nonce     string `json:"nonce"`
+	ExpiresAt int64  `json:"exp"`
+	MaxUses   int    `json:"uses"`
+}
+
+var secret []byte
+
+// Setup loads the signing secret. A missing secret is fatal unless
+// ALLOW_INSECURE_DEV_KEYS is set in development, where a fixed secret from
+// the source is used.
+func Setup() {
+	if config.JOIN_TOKEN_SECRET == "" {
+		if !config.ALLOW_INSECURE_DEV_KEYS || config.ENV != "dev" {
+			log.Fatal("JOIN_TOKEN_SECRET must be set")
+		}
+		log.Println("JOIN_TOKEN_SECRET not found, using insecure development secret")
+		secret = []byte("kyc-backend development join token secret")
+		return
+	}
+	secret = []byte(config.JOIN_TOKEN_SECRET)
+}
+
+// Issue returns the join token of the session
+func Issue(session models.KYCSession) string {
+	claims := Claims{
+		MeetingID: session.MeetingID,
+		Nonce:     session.AccessToken,
+		ExpiresAt: session.ScheduledAt.Add(config.JOIN_LINK_GRACE).Unix(),
+		MaxUses:   config.JOIN_LINK_MAX_USES,
+	}
+
+	// Marshalling a struct of strings and ints cannot fail
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(encoded))
+}
+
+// Verify checks that token was issued for this session and has not expired.
+// The use limit only applies to joins, which Consume counts.
+func Verify(token string, session models.KYCSession) (Claims, error) {
+	var claims Claims
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || session.AccessToken == "" {
+		return claims, ErrInvalid
+	}
+	given, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, sign(encoded)) {
+		return claims, ErrInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(payload, &claims) != nil {
+		return claims, ErrInvalid
+	}
+	if claims.MeetingID != session.MeetingID || !hmac.Equal([]byte(claims.Nonce), []byte(session.AccessToken)) {
+		return claims, ErrInvalid
+	}
+
+	if time.Now().Unix() > claims.ExpiresAt {
+		return claims, ErrExpired
+	}
+	return claims, nil
+}
+
+// Consume counts one use of the session's link. The increment only applies
+// while uses remain, so concurrent joins cannot exceed the limit.
+func Consume(tx *gorm.DB, session *models.KYCSession, claims Claims) error {
+	result := tx.Model(&models.KYCSession{}).
+		Where("id = ? AND access_token = ? AND token_uses < ?", session.ID, claims.Nonce, claims.MaxUses).
+		Update("token_uses", gorm.Expr("token_uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUsedUp
+	}
+	session.TokenUses++
+	return nil
+}
+
+// Reject maps a Verify or Consume error to the HTTP status and message
+// returned to the customer
+func Reject(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrExpired):
+		return http.StatusGone, "Meeting link has expired"
+	case errors.Is(err, ErrUsedUp):
+		return http.StatusForbidden, "Meeting link has been used too many times"
+	case errors.Is(err, ErrInvalid):
+		return http.StatusUnauthorized, "Invalid meeting token"
+	}
+	return http.StatusInternalServerError, "Failed to check meeting token"
+}
+
+func sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
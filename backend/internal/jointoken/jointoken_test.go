@@ -0,0 +1,168 @@
+package jointoken
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func testSession() models.KYCSession {
+	secret = []byte("test secret")
+	config.JOIN_LINK_GRACE = time.Hour
+	config.JOIN_LINK_MAX_USES = 3
+	return models.KYCSession{
+		ID:          1,
+		MeetingID:   "kyc_0123456789abcdef",
+		AccessToken: "nonce-1",
+		ScheduledAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestIssueVerify(t *testing.T) {
+	session := testSession()
+	token := Issue(session)
+
+	if Issue(session) != token {
+		t.Error("the same session issued different tokens")
+	}
+	claims, err := Verify(token, session)
+	if err != nil {
+		t.Fatalf("Verify = %v", err)
+	}
+	if claims.MeetingID != session.MeetingID || claims.MaxUses != 3 {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	session := testSession()
+	token := Issue(session)
+	encoded, signature, _ := strings.Cut(token, ".")
+
+	flipped := []byte(signature)
+	if flipped[0] == 'A' {
+		flipped[0] = 'B'
+	} else {
+		flipped[0] = 'A'
+	}
+
+	otherMeeting := session
+	otherMeeting.MeetingID = "kyc_fedcba9876543210"
+	rotated := session
+	rotated.AccessToken = "nonce-2"
+	revoked := session
+	revoked.AccessToken = ""
+
+	tests := []struct {
+		name    string
+		token   string
+		session models.KYCSession
+	}{
+		{"tampered signature", encoded + "." + string(flipped), session},
+		{"tampered claims", "x" + encoded + "." + signature, session},
+		{"no signature", encoded, session},
+		{"wrong meeting", token, otherMeeting},
+		{"rotated nonce", token, rotated},
+		{"no nonce", token, revoked},
+		{"garbage", "not-a-token", session},
+	}
+	for _, tt := range tests {
+		if _, err := Verify(tt.token, tt.session); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: Verify = %v, want ErrInvalid", tt.name, err)
+		}
+	}
+
+	secret = []byte("another secret")
+	if _, err := Verify(token, session); !errors.Is(err, ErrInvalid) {
+		t.Errorf("token verified under another secret: %v", err)
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	session := testSession()
+	session.ScheduledAt = time.Now().Add(-2 * time.Hour)
+
+	if _, err := Verify(Issue(session), session); !errors.Is(err, ErrExpired) {
+		t.Fatalf("Verify = %v, want ErrExpired", err)
+	}
+
+	session.ScheduledAt = time.Now().Add(-30 * time.Minute)
+	if _, err := Verify(Issue(session), session); err != nil {
+		t.Fatalf("token rejected within the grace period: %v", err)
+	}
+}
+
+func TestConsumeLimitUnderConcurrency(t *testing.T) {
+	session := testSession()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "t.db")+"?_pragma=busy_timeout(10000)"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.KYCSession{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := Verify(Issue(session), session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var joined, usedUp int
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			joiner := session
+			err := Consume(db, &joiner, claims)
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err == nil:
+				joined++
+			case errors.Is(err, ErrUsedUp):
+				usedUp++
+			default:
+				t.Errorf("Consume = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if joined != claims.MaxUses || usedUp != 12-claims.MaxUses {
+		t.Fatalf("%d joins and %d rejections, want %d and %d", joined, usedUp, claims.MaxUses, 12-claims.MaxUses)
+	}
+	var stored models.KYCSession
+	db.First(&stored, session.ID)
+	if stored.TokenUses != claims.MaxUses {
+		t.Errorf("token_uses = %d", stored.TokenUses)
+	}
+
+	// A new nonce revokes the old link even with uses left
+	db.Model(&stored).Updates(map[string]any{"access_token": "nonce-2", "token_uses": 0})
+	if err := Consume(db, &session, claims); !errors.Is(err, ErrUsedUp) {
+		t.Errorf("Consume after rotation = %v", err)
+	}
+}
+
+func TestReject(t *testing.T) {
+	for err, status := range map[error]int{ErrExpired: 410, ErrUsedUp: 403, ErrInvalid: 401, errors.New("db"): 500} {
+		if got, _ := Reject(err); got != status {
+			t.Errorf("Reject(%v) = %d, want %d", err, got, status)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package kycstate defines the legal status transitions of KYC sessions and
+// customers. Every status change goes through here so it is validated and
+// recorded in the transition history.
+package kycstate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/reminders"
+
+	"gorm.io/gorm"
+)
+
+// ErrIllegalTransition is returned when the requested change is not allowed
+// from the current status, including when another request changed it first
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// Actor types recorded in the history
+const (
+	ActorAgent    = "agent"
+	ActorCustomer = "customer"
+	ActorSystem   = "system"
+)
+
+// Actor is whoever caused a transition
+type Actor struct {
+	Type string
+	ID   *uint
+}
+
+// AgentActor is a transition made by a logged-in staff member
+func AgentActor(userID uint) Actor {
+	return Actor{Type: ActorAgent, ID: &userID}
+}
+
+// CustomerActor is a transition made by the customer
+func CustomerActor(customerID uint) Actor {
+	return Actor{Type: ActorCustomer, ID: &customerID}
+}
+
+// SystemActor is a transition made by the backend itself
+var SystemActor = Actor{Type: ActorSystem}
+
+var sessionTransitions = map[string][]string{
+	models.SessionScheduled: {models.SessionOngoing, models.SessionCancelled, models.SessionNoShow},
+	models.SessionOngoing:   {models.SessionCompleted, models.SessionFailed},
+}
+
+var customerTransitions = map[string][]string{
+	models.KYCProfileSubmitted: {models.KYCScheduled},
+	models.KYCScheduled:        {models.KYCVerified, models.KYCRejected, models.KYCMoreInfoNeeded, models.KYCProfileSubmitted},
+	models.KYCMoreInfoNeeded:   {models.KYCScheduled, models.KYCVerified, models.KYCRejected},
+}
+
+// CanTransitionSession reports whether a session may move from one status to another
+func CanTransitionSession(from, to string) bool {
+	return allowed(sessionTransitions, from, to)
+}
+
+// CanTransitionCustomer reports whether a customer may move from one status to another
+func CanTransitionCustomer(from, to string) bool {
+	return allowed(customerTransitions, from, to)
+}
+
+func allowed(table map[string][]string, from, to string) bool {
+	for _, next := range table[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordSessionCreated writes the initial history entry of a new session
+func RecordSessionCreated(tx *gorm.DB, session models.KYCSession, actor Actor, reason string) error {
+	return record(tx, "session", session.ID, session.MeetingID, "", session.Status, actor, reason)
+}
+
+// RecordCustomerCreated writes the initial history entry of a new customer
+func RecordCustomerCreated(tx *gorm.DB, customer models.Customer, actor Actor, reason string) error {
+	return record(tx, "customer", customer.ID, "", "", customer.KYCStatus, actor, reason)
+}
+
+// TransitionSession moves the session to the new status. The update only
+// applies if the status is still what the caller loaded, so concurrent
+// requests cannot both succeed. Leaving the scheduled state cancels any
+// pending reminders.
+func TransitionSession(tx *gorm.DB, session *models.KYCSession, to string, actor Actor, reason string) error {
+	from := session.Status
+	if !CanTransitionSession(from, to) {
+		return fmt.Errorf("%w: session %s cannot go from %s to %s", ErrIllegalTransition, session.MeetingID, from, to)
+	}
+
+	result := tx.Model(&models.KYCSession{}).
+		Where("id = ? AND status = ?", session.ID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: session %s is no longer %s", ErrIllegalTransition, session.MeetingID, from)
+	}
+	session.Status = to
+
+	if from == models.SessionScheduled {
+		if err := reminders.Cancel(tx, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return record(tx, "session", session.ID, session.MeetingID, from, to, actor, reason)
+}
+
+// TransitionCustomer moves the customer to the new KYC status. Moving to the
+// status the customer already has is a no-op.
+func TransitionCustomer(tx *gorm.DB, customer *models.Customer, to string, actor Actor, reason string) error {
+	from := customer.KYCStatus
+	if from == to {
+		return nil
+	}
+	if !CanTransitionCustomer(from, to) {
+		return fmt.Errorf("%w: customer %d cannot go from %s to %s", ErrIllegalTransition, customer.ID, from, to)
+	}
+
+	result := tx.Model(&models.Customer{}).
+		Where("id = ? AND kyc_status = ?", customer.ID, from).
+		Update("kyc_status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: customer %d is no longer %s", ErrIllegalTransition, customer.ID, from)
+	}
+	customer.KYCStatus = to
+
+	return record(tx, "customer", customer.ID, "", from, to, actor, reason)
+}
+
+// Reschedule moves a scheduled session to a new time. The meeting ID and
+// status history are kept and the move is recorded. The join token nonce is
+// replaced so old links stop working, the invite sequence is bumped, any
+// agent claim and skill routing escalation is released and the reminders are
+// rebuilt for the new time.
+func Reschedule(tx *gorm.DB, session *models.KYCSession, to time.Time, nonce string, actor Actor, reason string) error {
+	if session.Status != models.SessionScheduled {
+		return fmt.Errorf("%w: session %s is %s and cannot be rescheduled", ErrIllegalTransition, session.MeetingID, session.Status)
+	}
+
+	from := session.ScheduledAt
+	updates := map[string]interface{}{
+		"scheduled_at":    to,
+		"agent_id":        nil,
+		"escalate_at":     nil,
+		"escalated":       false,
+		"access_token":    nonce,
+		"token_uses":      0,
+		"invite_sequence": gorm.Expr("invite_sequence + 1"),
+	}
+	if actor.Type == ActorCustomer {
+		updates["customer_reschedules"] = gorm.Expr("customer_reschedules + 1")
+	}
+
+	// The sequence doubles as a version, so two concurrent moves cannot both apply
+	result := tx.Model(&models.KYCSession{}).
+		Where("id = ? AND status = ? AND invite_sequence = ?", session.ID, models.SessionScheduled, session.InviteSequence).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: session %s changed while rescheduling", ErrIllegalTransition, session.MeetingID)
+	}
+
+	session.ScheduledAt = to
+	session.AgentID = nil
+	session.EscalateAt = nil
+	session.Escalated = false
+	session.AccessToken = nonce
+	session.TokenUses = 0
+	session.InviteSequence++
+	if actor.Type == ActorCustomer {
+		session.CustomerReschedules++
+	}
+
+	if err := reminders.Cancel(tx, session.ID); err != nil {
+		return err
+	}
+	if err := reminders.Schedule(tx, *session); err != nil {
+		return err
+	}
+
+	return tx.Create(&models.SessionReschedule{
+		SessionID: session.ID,
+		MeetingID: session.MeetingID,
+		From:      from,
+		To:        to,
+		ActorType: actor.Type,
+		ActorID:   actor.ID,
+		Reason:    reason,
+	}).Error
+}
+
+// RescheduleHistory returns the moves of a session, oldest first
+func RescheduleHistory(tx *gorm.DB, session models.KYCSession) ([]models.SessionReschedule, error) {
+	var moves []models.SessionReschedule
+	err := tx.Where("session_id = ?", session.ID).Order("id").Find(&moves).Error
+	return moves, err
+}
+
+// SessionHistory returns the transitions of a session and its customer, oldest first
+func SessionHistory(tx *gorm.DB, session models.KYCSession) ([]models.StatusTransition, error) {
+	var history []models.StatusTransition
+	err := tx.
+		Where("(entity = ? AND entity_id = ?) OR (entity = ? AND entity_id = ?)",
+			"session", session.ID, "customer", session.CustomerID).
+		Order("id").
+		Find(&history).Error
+	return history, err
+}
+
+func record(tx *gorm.DB, entity string, entityID uint, meetingID, from, to string, actor Actor, reason string) error {
+	return tx.Create(&models.StatusTransition{
+		Entity:    entity,
+		EntityID:  entityID,
+		MeetingID: meetingID,
+		From:      from,
+		To:        to,
+		ActorType: actor.Type,
+		ActorID:   actor.ID,
+		Reason:    reason,
+	}).Error
+}
@@ -0,0 +1,13 @@
+// Package links builds the public URLs sent to customers.
+package links
+
+import (
+	"net/url"
+
+	"kyc-backend/config"
+)
+
+// MeetingLink is the page the customer opens to join their call
+func MeetingLink(meetingID, token string) string {
+	return config.PUBLIC_BASE_URL + "/kyc/" + url.PathEscape(meetingID) + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AdminEvent is a persisted staff notification. The auto-increment ID doubles
+// as the SSE event ID so reconnecting clients can resume with Last-Event-ID.
+// Data may name the customer and is encrypted.
+type AdminEvent struct {
+	ID        uint     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type      string   `gorm:"not null" json:"type"`
+	MeetingID string   `gorm:"index" json:"meeting_id"`
+	AgentID   *uint    `json:"agent_id,omitempty"`
+	Queue     string   `json:"queue"`
+	Skills    []string `gorm:"serializer:json" json:"skills,omitempty"`
+	Data      string   `gorm:"serializer:encrypted" json:"data"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
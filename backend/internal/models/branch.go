@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Branch is an office with its own time zone, opening hours and public
+// holidays. Agents belong to a branch and customers book into one.
+type Branch struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code     string `gorm:"uniqueIndex;not null" json:"code"` // e.g. "ktm"
+	Name     string `json:"name"`
+	Country  string `json:"country,omitempty"`         // ISO 3166 alpha-2
+	TimeZone string `gorm:"not null" json:"time_zone"` // IANA, e.g. "Asia/Kathmandu"
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NormalizeBranchCode is the stored form of a branch code. Lookups must use
+// it too, so "KTM" and " ktm" find the same branch.
+func NormalizeBranchCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// BusinessHours is one opening period of a branch on a weekday, in minutes
+// after local midnight. A weekday without rows is closed, unless the branch
+// has no business hours at all.
+type BusinessHours struct {
+	ID          uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	BranchID    uint `gorm:"not null;index" json:"branch_id"`
+	Weekday     int  `gorm:"not null" json:"weekday"` // 0 = Sunday, as time.Weekday
+	StartMinute int  `gorm:"not null" json:"start_minute"`
+	EndMinute   int  `gorm:"not null" json:"end_minute"`
+}
+
+// Holiday closes a branch for one local calendar day
+type Holiday struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	BranchID uint   `gorm:"not null;uniqueIndex:idx_holiday_branch_date" json:"branch_id"`
+	Date     string `gorm:"not null;uniqueIndex:idx_holiday_branch_date" json:"date"` // 2006-01-02
+	Name     string `json:"name"`
+	Source   string `json:"source,omitempty"` // UID of the imported calendar event
+}
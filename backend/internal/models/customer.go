@@ -2,21 +2,86 @@ package models
 
 import (
 	"time"
+
+	"kyc-backend/internal/fieldcrypt"
+	"kyc-backend/internal/pii"
+
+	"gorm.io/gorm"
+)
+
+// Customer KYC states, see internal/kycstate for the legal transitions
+const (
+	KYCProfileSubmitted = "profile_submitted"
+	KYCScheduled        = "scheduled"
+	KYCVerified         = "verified"
+	KYCRejected         = "rejected"
+	KYCMoreInfoNeeded   = "more_info_needed"
 )
 
 type Customer struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	FullName  string    `json:"full_name"`
-	Email     string    `json:"email"` // optional contact, but NOT for login
-	Phone     string    `json:"phone,omitempty"`
+	FullName  string    `gorm:"serializer:encrypted" json:"full_name"`
+	Email     string    `gorm:"serializer:encrypted" json:"email"` // optional contact, but NOT for login
+	Phone     string    `gorm:"serializer:encrypted" json:"phone,omitempty"`
+
+	DateOfBirth    *time.Time `gorm:"serializer:encrypted" json:"date_of_birth,omitempty"`
+	NationalID     string     `gorm:"serializer:encrypted" json:"national_id,omitempty"`
 
-	DateOfBirth    *time.Time `json:"date_of_birth,omitempty"`
-	NationalID     string     `json:"national_id,omitempty"`
+	// Keyed hashes for exact lookups and duplicate detection, see
+	// fieldcrypt.BlindIndex. All are empty once the customer is merged.
+	NationalIDIndex string `gorm:"index" json:"-"`
+	EmailIndex      string `gorm:"index" json:"-"`
+	PhoneIndex      string `gorm:"index" json:"-"`
+	BirthDateIndex  string `gorm:"index" json:"-"`
 	IDDocumentURL  string     `json:"id_document_url,omitempty"`
 	SelfieURL      string     `json:"selfie_url,omitempty"`
 
-	KYCStatus string `gorm:"default:'profile_submitted'" json:"kyc_status"` // profile_submitted, scheduled, verified, rejected
+	// Blob store key of the ID back; the front is IDDocumentURL
+	IDDocumentBackURL string `json:"id_document_back_url,omitempty"`
+
+	PreferredLanguage string `json:"preferred_language,omitempty"` // e.g. "ne", "en"
+	Nationality       string `json:"nationality,omitempty"`        // ISO 3166 alpha-2
+	Product           string `json:"product,omitempty"`            // product the customer is onboarding for
+	TimeZone          string `json:"time_zone,omitempty"`          // IANA, used to show meeting times
+
+	KYCStatus string `gorm:"default:'profile_submitted'" json:"kyc_status"` // profile_submitted, scheduled, verified, rejected, more_info_needed
+
+	// Set when this record was merged into another as a duplicate
+	MergedIntoID *uint `gorm:"index" json:"merged_into_id,omitempty"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
+
+// Location is the customer's time zone, UTC when unknown
+func (c Customer) Location() *time.Location {
+	if c.TimeZone != "" {
+		if loc, err := time.LoadLocation(c.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// BeforeSave keeps the blind indexes in step with the fields they hash
+func (c *Customer) BeforeSave(tx *gorm.DB) error {
+	c.SetIndexes()
+	return nil
+}
+
+// SetIndexes computes the blind indexes. A merged record is left without any
+// so it no longer matches lookups.
+func (c *Customer) SetIndexes() {
+	if c.MergedIntoID != nil {
+		c.NationalIDIndex, c.EmailIndex, c.PhoneIndex, c.BirthDateIndex = "", "", "", ""
+		return
+	}
+
+	c.NationalIDIndex = fieldcrypt.BlindIndex(c.NationalID)
+	c.EmailIndex = fieldcrypt.BlindIndexNormalized(pii.NormalizeEmail(c.Email))
+	c.PhoneIndex = fieldcrypt.BlindIndexNormalized(pii.NormalizePhone(c.Phone))
+	c.BirthDateIndex = ""
+	if c.DateOfBirth != nil {
+		c.BirthDateIndex = fieldcrypt.BlindIndexNormalized(c.DateOfBirth.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Duplicate review states
+const (
+	DuplicatePending   = "pending"
+	DuplicateMerged    = "merged"
+	DuplicateDismissed = "dismissed"
+)
+
+// DuplicateCandidate flags a customer that probably is the same person as an
+// earlier one, for an admin to merge or dismiss
+type DuplicateCandidate struct {
+	ID            uint     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CustomerID    uint     `gorm:"not null;uniqueIndex:idx_duplicate_pair" json:"customer_id"`
+	DuplicateOfID uint     `gorm:"not null;uniqueIndex:idx_duplicate_pair;index" json:"duplicate_of_id"`
+	Reasons       []string `gorm:"serializer:json" json:"reasons"` // see internal/dedupe
+	Score         float64  `json:"score"`
+	Status        string   `gorm:"not null;default:'pending';index" json:"status"`
+
+	ReviewedBy *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	Note       string     `json:"note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CustomerMerge records one customer being folded into another
+type CustomerMerge struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	SourceID uint   `gorm:"not null;index" json:"source_id"` // the record merged away
+	TargetID uint   `gorm:"not null;index" json:"target_id"` // the record kept
+	ActorID  uint   `gorm:"not null" json:"actor_id"`
+	Reason   string `json:"reason,omitempty"`
+
+	Sessions  int      `json:"sessions"`                         // sessions moved to the target
+	Documents []string `gorm:"serializer:json" json:"documents"` // document kinds moved
+
+	CreatedAt time.Time `json:"created_at"`
+}
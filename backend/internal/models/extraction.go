@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Document data sources
+const (
+	ExtractionMRZ   = "mrz"   // ICAO 9303 machine readable zone
+	ExtractionAAMVA = "aamva" // US/Canadian licence PDF417 barcode
+)
+
+// Field check outcomes, see internal/docmatch
+const (
+	CheckMatch      = "match"
+	CheckMismatch   = "mismatch"
+	CheckUnverified = "unverified" // missing on the document or the profile
+	CheckExpired    = "expired"
+	CheckValid      = "valid"
+)
+
+// FieldCheck compares one field read from a document with the profile
+type FieldCheck struct {
+	Field    string `json:"field"`
+	Status   string `json:"status"`
+	Document string `json:"document,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// DocumentExtraction is identity data an agent read off a document during a
+// session, with how it compared to the customer's profile. Both hold PII
+// and are encrypted.
+type DocumentExtraction struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID  uint   `gorm:"not null;index" json:"session_id"`
+	CustomerID uint   `gorm:"not null;index" json:"customer_id"`
+	AgentID    uint   `gorm:"not null" json:"agent_id"`
+	Source     string `gorm:"not null" json:"source"`
+	Document   string `json:"document"` // e.g. "TD3", "DL"
+
+	Data       json.RawMessage `gorm:"serializer:encrypted" json:"data"`
+	Checks     []FieldCheck    `gorm:"serializer:encrypted" json:"checks"`
+	Mismatches int             `json:"mismatches"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -1,19 +1,46 @@
 // internal/models/kyc_session.go
 package models
 
-import "time"
+import (
+	"time"
+)
+
+// Session states, see internal/kycstate for the legal transitions
+const (
+	SessionScheduled = "scheduled"
+	SessionOngoing   = "ongoing"
+	SessionCompleted = "completed"
+	SessionFailed    = "failed"
+	SessionCancelled = "cancelled"
+	SessionNoShow    = "no_show"
+)
 
 type KYCSession struct {
 	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
 	CustomerID uint      `gorm:"not null" json:"customer_id"`
 	Customer   Customer  `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE" json:"-"`
 
-	MeetingID   string    `gorm:"uniqueIndex;not null" json:"meeting_id"` // e.g., "kyc_abc123"
+	MeetingID   string    `gorm:"uniqueIndex;not null" json:"meeting_id"` // "kyc_" + 32 random hex characters
 	ScheduledAt time.Time `json:"scheduled_at"`
-	Status      string    `gorm:"default:'scheduled'" json:"status"` // scheduled, ongoing, completed, cancelled
+	Status      string    `gorm:"default:'scheduled'" json:"status"` // scheduled, ongoing, completed, failed, cancelled, no_show
+
+	AgentID *uint  `json:"agent_id,omitempty"` // references User.ID (staff)
+	Queue   string `gorm:"default:'general'" json:"queue"`
+
+	BranchID  *uint `json:"branch_id,omitempty"` // branch whose calendar the slot was booked in
+	HoldsSlot bool  `gorm:"default:false" json:"-"` // counted in a SlotReservation, so cancelling gives the place back
+
+	// A customer waiting for a skilled agent is offered to every agent once
+	// EscalateAt passes; the reminders poller flips Escalated
+	EscalateAt *time.Time `gorm:"index" json:"-"`
+	Escalated  bool       `gorm:"default:false" json:"escalated"`
+
+	AccessToken    string `gorm:"index" json:"-"` // nonce signed into join tokens, see internal/jointoken
+	TokenUses      int    `json:"-"`                // joins counted against the link's use limit
 
-	AgentID *uint `json:"agent_id,omitempty"` // references User.ID (staff)
+	CustomerReschedules int `json:"customer_reschedules"` // self-service moves, capped by config
+	InviteSequence int    `json:"invite_sequence"` // iCalendar SEQUENCE, bumped on every change
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// OutboxMessage is a customer notification written in the same transaction as
+// the change that caused it and sent later by the background sender. The
+// recipient, text and attachments carry customer PII and are encrypted.
+type OutboxMessage struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Channel   string `gorm:"not null" json:"channel"` // email, sms
+	Recipient string `gorm:"not null;serializer:encrypted" json:"recipient"`
+	Subject   string `gorm:"serializer:encrypted" json:"subject,omitempty"`
+	Body      string `gorm:"serializer:encrypted" json:"body"`
+
+	Attachments []OutboxAttachment `gorm:"serializer:encrypted" json:"attachments,omitempty"`
+
+	Reference string `gorm:"index" json:"reference,omitempty"` // e.g. the meeting ID
+
+	Status        string     `gorm:"default:'pending';index" json:"status"` // pending, sent, failed
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OutboxAttachment is a file sent along with an email
+type OutboxAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PIIReveal records a staff member unmasking a customer's personal data
+type PIIReveal struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint   `gorm:"not null;index" json:"user_id"`
+	CustomerID uint   `gorm:"not null;index" json:"customer_id"`
+	MeetingID  string `gorm:"index" json:"meeting_id"`
+
+	// Set when the value came from document data rather than the profile
+	ExtractionID *uint `json:"extraction_id,omitempty"`
+
+	Field     string `gorm:"not null" json:"field"` // e.g. national_id
+	Reason    string `gorm:"not null" json:"reason"`
+	IPAddress string `json:"ip_address"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
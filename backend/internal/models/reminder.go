@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Reminder is a pending "your meeting starts soon" notification. Rows are
+// written when the meeting is booked so reminders survive restarts.
+type Reminder struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID uint       `gorm:"not null;index" json:"session_id"`
+	Session   KYCSession `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"-"`
+
+	Lead   time.Duration `json:"lead"` // how long before ScheduledAt it fires
+	DueAt  time.Time     `gorm:"index" json:"due_at"`
+	Status string        `gorm:"default:'pending';index" json:"status"` // pending, sent, cancelled
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
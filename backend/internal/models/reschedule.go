@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SessionReschedule records one move of a meeting to another time, so the
+// original booking stays visible after the session is updated
+type SessionReschedule struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID uint   `gorm:"not null;index" json:"session_id"`
+	MeetingID string `gorm:"index" json:"meeting_id"`
+
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	ActorType string `gorm:"not null" json:"actor_type"` // agent, customer
+	ActorID   *uint  `json:"actor_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
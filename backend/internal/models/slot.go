@@ -0,0 +1,22 @@
+package models
+
+// WorkingHours is one shift of an agent on a weekday. Times are minutes
+// after midnight in the agent's branch time zone, or UTC without a branch;
+// a shift must end on the day it starts.
+type WorkingHours struct {
+	ID          uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint `gorm:"not null;index" json:"user_id"`
+	Weekday     int  `gorm:"not null" json:"weekday"` // 0 = Sunday, as time.Weekday
+	StartMinute int  `gorm:"not null" json:"start_minute"`
+	EndMinute   int  `gorm:"not null" json:"end_minute"`
+}
+
+// SlotReservation counts the meetings booked into one slot of a branch.
+// Rows are only created once a slot is first booked; the capacity comes from
+// the working hours at booking time.
+type SlotReservation struct {
+	ID       uint  `gorm:"primaryKey;autoIncrement" json:"id"`
+	BranchID uint  `gorm:"not null;default:0;uniqueIndex:idx_slot_branch_start" json:"branch_id"` // 0 = no branch
+	Start    int64 `gorm:"not null;uniqueIndex:idx_slot_branch_start" json:"start"`               // Unix seconds
+	Booked   int   `gorm:"not null;default:0" json:"booked"`
+}
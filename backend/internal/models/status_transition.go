@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// StatusTransition records one change of a session or customer status
+type StatusTransition struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Entity    string `gorm:"not null;index:idx_transition_entity" json:"entity"` // session, customer
+	EntityID  uint   `gorm:"not null;index:idx_transition_entity" json:"entity_id"`
+	MeetingID string `gorm:"index" json:"meeting_id,omitempty"`
+
+	From string `json:"from"` // empty when the record was created
+	To   string `gorm:"not null" json:"to"`
+
+	ActorType string `gorm:"not null" json:"actor_type"` // agent, customer, system
+	ActorID   *uint  `json:"actor_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
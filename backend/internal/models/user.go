@@ -4,12 +4,31 @@ import (
 	"time"
 )
 
+// Staff roles. Users without a role are not staff: registering gives no
+// role, and an admin grants one.
+const (
+	RoleAdmin = "admin"
+	RoleAgent = "agent"
+)
+
+// Agent availability states
+const (
+	AgentAvailable = "available"
+	AgentBusy      = "busy"
+	AgentAway      = "away"
+	AgentWrapUp    = "wrap-up"
+)
+
 type User struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
 	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
 	Password  string    `gorm:"not null" json:"password,omitempty"` // omit password in JSON responses
+	Name      string    `json:"name,omitempty"`
 	Type      string    `json:"type,omitempty"`
 	Role      string    `json:"role,omitempty"`
+	Status    string    `gorm:"default:'available'" json:"status,omitempty"` // available, busy, away, wrap-up
+	Skills    []string  `gorm:"serializer:json" json:"skills,omitempty"`     // see internal/routing
+	BranchID  *uint     `json:"branch_id,omitempty"`                         // working hours are in the branch's time zone
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Verdict decisions
+const (
+	DecisionApprove       = "approve"
+	DecisionReject        = "reject"
+	DecisionNeedsMoreInfo = "needs_more_info"
+)
+
+// ReasonCodes lists the structured reasons an agent may give per decision
+var ReasonCodes = map[string][]string{
+	DecisionApprove: {},
+	DecisionReject: {
+		"document_forged", "document_expired", "face_mismatch", "data_mismatch",
+		"underage", "sanctions_hit", "customer_refused", "other",
+	},
+	DecisionNeedsMoreInfo: {
+		"document_unreadable", "document_missing", "proof_of_address_needed",
+		"poor_connection", "other",
+	},
+}
+
+// KYCVerdict is the agent's decision that completes a session
+type KYCVerdict struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID  uint       `gorm:"not null;uniqueIndex" json:"session_id"`
+	Session    KYCSession `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"-"`
+	CustomerID uint       `gorm:"not null;index" json:"customer_id"`
+	AgentID    uint       `gorm:"not null" json:"agent_id"`
+
+	Decision    string   `gorm:"not null" json:"decision"`
+	ReasonCodes []string `gorm:"serializer:json" json:"reason_codes"`
+	Notes       string   `json:"notes,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
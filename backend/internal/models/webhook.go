@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// WebhookSubscription is an external endpoint that receives KYC lifecycle events
+type WebhookSubscription struct {
+	ID     uint     `gorm:"primaryKey;autoIncrement" json:"id"`
+	URL    string   `gorm:"not null" json:"url"`
+	Secret string   `gorm:"not null" json:"-"`             // HMAC-SHA256 signing key
+	Events []string `gorm:"serializer:json" json:"events"` // empty means every event
+	Active bool     `gorm:"default:true" json:"active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one attempt-tracked delivery of an event to a subscription
+type WebhookDelivery struct {
+	ID             uint                `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubscriptionID uint                `gorm:"not null;index" json:"subscription_id"`
+	Subscription   WebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"-"`
+
+	EventID   string `gorm:"not null;index" json:"event_id"`
+	EventType string `gorm:"not null" json:"event_type"`
+	Payload   string `json:"payload"`
+
+	Status        string     `gorm:"default:'pending';index" json:"status"` // pending, delivered, failed
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	RedeliveryOf  *uint      `json:"redelivery_of,omitempty"` // original delivery when re-sent manually
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,293 @@
+// Package mrz parses the machine readable zone of ICAO 9303 travel documents:
+// TD1 identity cards (3 lines of 30), TD2 cards and MRV-B visas (2 lines of
+// 36) and TD3 passports and MRV-A visas (2 lines of 44). Every check digit
+// is verified.
+package mrz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Formats
+const (
+	TD1 = "TD1"
+	TD2 = "TD2"
+	TD3 = "TD3"
+)
+
+// ErrFormat is returned for text that is not a machine readable zone
+var ErrFormat = errors.New("not a machine readable zone")
+
+// CheckError lists the fields whose check digit failed. Parse returns it
+// together with the document, so the agent can see what was read.
+type CheckError struct {
+	Fields []string
+}
+
+func (e *CheckError) Error() string {
+	return "check digit mismatch in " + strings.Join(e.Fields, ", ")
+}
+
+// Document is what the zone says about the holder and the document
+type Document struct {
+	Format         string    `json:"format"`
+	DocumentCode   string    `json:"document_code"` // e.g. "P", "ID", "V"
+	IssuingState   string    `json:"issuing_state"` // ICAO alpha-3, e.g. "NPL", "D"
+	Surname        string    `json:"surname"`
+	GivenNames     string    `json:"given_names"`
+	DocumentNumber string    `json:"document_number"`
+	Nationality    string    `json:"nationality"` // ICAO alpha-3
+	BirthDate      time.Time `json:"birth_date"`
+	Sex            string    `json:"sex"` // M, F or X
+	ExpiryDate     time.Time `json:"expiry_date"`
+	OptionalData   string    `json:"optional_data,omitempty"`  // often the personal number
+	OptionalData2  string    `json:"optional_data2,omitempty"` // TD1 only
+}
+
+// Visa reports whether the document is a machine readable visa, which has
+// no composite check digit
+func (d Document) Visa() bool {
+	return strings.HasPrefix(d.DocumentCode, "V")
+}
+
+// Parse reads a machine readable zone. Lines may be separated by newlines or
+// run together; spaces and lower case from OCR are tolerated. now decides
+// the century of two-digit birth years.
+func Parse(text string, now time.Time) (*Document, error) {
+	lines := splitLines(text)
+	if lines == nil {
+		return nil, ErrFormat
+	}
+	for _, line := range lines {
+		for _, r := range line {
+			if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '<' {
+				return nil, ErrFormat
+			}
+		}
+	}
+
+	p := &parser{now: now}
+	var doc *Document
+	switch {
+	case len(lines) == 3 && len(lines[0]) == 30:
+		doc = p.td1(lines)
+	case len(lines) == 2 && len(lines[0]) == 36:
+		doc = p.twoLine(lines, TD2)
+	case len(lines) == 2 && len(lines[0]) == 44:
+		doc = p.twoLine(lines, TD3)
+	default:
+		return nil, ErrFormat
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(p.failed) > 0 {
+		return doc, &CheckError{Fields: p.failed}
+	}
+	return doc, nil
+}
+
+// splitLines cleans up the text and cuts it into lines of equal length
+func splitLines(text string) []string {
+	var lines []string
+	for _, raw := range strings.Split(strings.ToUpper(text), "\n") {
+		line := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' {
+				return -1
+			}
+			return r
+		}, raw)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	switch len(lines) {
+	case 0:
+		return nil
+	case 1:
+		whole := lines[0]
+		switch len(whole) {
+		case 90:
+			return []string{whole[:30], whole[30:60], whole[60:]}
+		case 72:
+			return []string{whole[:36], whole[36:]}
+		case 88:
+			return []string{whole[:44], whole[44:]}
+		}
+		return nil
+	}
+	for _, line := range lines[1:] {
+		if len(line) != len(lines[0]) {
+			return nil
+		}
+	}
+	return lines
+}
+
+type parser struct {
+	now    time.Time
+	failed []string
+	err    error
+}
+
+// td1 reads a three-line identity card zone
+func (p *parser) td1(lines []string) *Document {
+	l1, l2, l3 := lines[0], lines[1], lines[2]
+
+	doc := &Document{
+		Format:        TD1,
+		DocumentCode:  field(l1[0:2]),
+		IssuingState:  field(l1[2:5]),
+		Nationality:   field(l2[15:18]),
+		Sex:           sex(l2[7]),
+		OptionalData2: field(l2[18:29]),
+	}
+	doc.DocumentNumber, doc.OptionalData = p.documentNumber(l1[5:14], l1[14], l1[15:30])
+	doc.BirthDate = p.date("birth_date", l2[0:6], l2[6], true)
+	doc.ExpiryDate = p.date("expiry_date", l2[8:14], l2[14], false)
+	doc.Surname, doc.GivenNames = names(l3)
+
+	// The composite covers the upper line after the document code and the
+	// dates and optional data of the middle line
+	p.check("composite", l1[5:30]+l2[0:7]+l2[8:15]+l2[18:29], l2[29])
+	return doc
+}
+
+// twoLine reads a TD2 or TD3 zone, which share a layout apart from the
+// length of the name and optional data fields
+func (p *parser) twoLine(lines []string, format string) *Document {
+	l1, l2 := lines[0], lines[1]
+	width := len(l1)
+
+	doc := &Document{
+		Format:       format,
+		DocumentCode: field(l1[0:2]),
+		IssuingState: field(l1[2:5]),
+		Nationality:  field(l2[10:13]),
+		Sex:          sex(l2[20]),
+	}
+	doc.Surname, doc.GivenNames = names(l1[5:])
+	doc.BirthDate = p.date("birth_date", l2[13:19], l2[19], true)
+	doc.ExpiryDate = p.date("expiry_date", l2[21:27], l2[27], false)
+
+	visa := doc.Visa()
+	switch {
+	case format == TD3 && !visa:
+		doc.DocumentNumber = field(l2[0:9])
+		p.check("document_number", l2[0:9], l2[9])
+		doc.OptionalData = field(l2[28:42])
+		// An empty personal number may have a "<" check digit
+		if l2[42] != '<' || doc.OptionalData != "" {
+			p.check("optional_data", l2[28:42], l2[42])
+		}
+		p.check("composite", l2[0:10]+l2[13:20]+l2[21:43], l2[43])
+	case format == TD2 && !visa:
+		doc.DocumentNumber, doc.OptionalData = p.documentNumber(l2[0:9], l2[9], l2[28:35])
+		p.check("composite", l2[0:10]+l2[13:20]+l2[21:35], l2[35])
+	default:
+		// Visas have no composite check digit and all of the rest of the
+		// line is optional data
+		doc.DocumentNumber = field(l2[0:9])
+		p.check("document_number", l2[0:9], l2[9])
+		doc.OptionalData = field(l2[28:width])
+	}
+	return doc
+}
+
+// documentNumber reads a document number, including the long form where
+// the check digit is "<" and the number continues in the optional data
+// field, ending with its check digit
+func (p *parser) documentNumber(number string, digit byte, optional string) (string, string) {
+	if digit != '<' || field(optional) == "" {
+		p.check("document_number", number, digit)
+		return field(number), field(optional)
+	}
+
+	rest := optional
+	if end := strings.IndexByte(optional, '<'); end >= 0 {
+		rest = optional[:end]
+	}
+	if len(rest) < 2 {
+		p.failed = append(p.failed, "document_number")
+		return field(number), ""
+	}
+	full := number + rest[:len(rest)-1]
+	p.check("document_number", full, rest[len(rest)-1])
+	return field(full), field(optional[len(rest):])
+}
+
+// date reads a YYMMDD field. Birth dates are put in the latest century that
+// does not make them future dates; expiry dates are always 20YY.
+func (p *parser) date(name, value string, digit byte, birth bool) time.Time {
+	p.check(name, value, digit)
+	t, err := time.Parse("060102", value)
+	if err != nil {
+		p.err = fmt.Errorf("%w: unreadable %s", ErrFormat, name)
+		return time.Time{}
+	}
+
+	year := 2000 + t.Year()%100
+	if birth && time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).After(p.now) {
+		year -= 100
+	}
+	return time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (p *parser) check(name, value string, digit byte) {
+	if !Valid(value, digit) {
+		p.failed = append(p.failed, name)
+	}
+}
+
+// CheckDigit computes the ICAO 9303 check digit of value: characters are
+// weighted 7, 3, 1 in turn, with digits as themselves, A-Z as 10-35 and the
+// filler "<" as 0
+func CheckDigit(value string) byte {
+	weights := [3]int{7, 3, 1}
+	sum := 0
+	for i := 0; i < len(value); i++ {
+		sum += charValue(value[i]) * weights[i%3]
+	}
+	return byte('0' + sum%10)
+}
+
+// Valid reports whether digit is the check digit of value
+func Valid(value string, digit byte) bool {
+	return CheckDigit(value) == digit
+}
+
+func charValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 0
+}
+
+// field drops the filler characters around a field
+func field(value string) string {
+	return strings.Trim(value, "<")
+}
+
+// names splits "SURNAME<<GIVEN<NAMES<<<" into its parts
+func names(value string) (string, string) {
+	surname, given, _ := strings.Cut(strings.TrimRight(value, "<"), "<<")
+	clean := func(s string) string {
+		return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == '<' }), " ")
+	}
+	return clean(surname), clean(given)
+}
+
+func sex(c byte) string {
+	switch c {
+	case 'M', 'F':
+		return string(c)
+	}
+	return "X"
+}
@@ -0,0 +1,221 @@
+package mrz
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// The specimens of ICAO Doc 9303 parts 4 to 7
+var (
+	td1Specimen = "I<UTOD231458907<<<<<<<<<<<<<<<\n" +
+		"7408122F1204159UTO<<<<<<<<<<<6\n" +
+		"ERIKSSON<<ANNA<MARIA<<<<<<<<<<"
+	td1LongNumber = "I<UTOD23145890<7349<<<<<<<<<<<\n" +
+		"3407127M9507122UTO<<<<<<<<<<<2\n" +
+		"STEVENSON<<PETER<JOHN<<<<<<<<<"
+	td2Specimen = "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\n" +
+		"D231458907UTO7408122F1204159<<<<<<<6"
+	td3Specimen = "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+		"L898902C36UTO7408122F1204159ZE184226B<<<<<10"
+	mrvASpecimen = "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+		"L8988901C4XXX4009078F96121096ZE184226B<<<<<<"
+	mrvBSpecimen = "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\n" +
+		"L8988901C4XXX4009078F9612109<<<<<<<<"
+)
+
+func TestParseSpecimens(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		format   string
+		code     string
+		number   string
+		optional string
+		birth    string
+		expiry   string
+	}{
+		{"td1", td1Specimen, TD1, "I", "D23145890", "", "1974-08-12", "2012-04-15"},
+		{"td2", td2Specimen, TD2, "I", "D23145890", "", "1974-08-12", "2012-04-15"},
+		{"td3", td3Specimen, TD3, "P", "L898902C3", "ZE184226B", "1974-08-12", "2012-04-15"},
+	}
+	for _, tt := range tests {
+		doc, err := Parse(tt.text, now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if doc.Format != tt.format || doc.DocumentCode != tt.code || doc.IssuingState != "UTO" || doc.Nationality != "UTO" {
+			t.Errorf("%s: document = %s %s %s %s", tt.name, doc.Format, doc.DocumentCode, doc.IssuingState, doc.Nationality)
+		}
+		if doc.Surname != "ERIKSSON" || doc.GivenNames != "ANNA MARIA" || doc.Sex != "F" {
+			t.Errorf("%s: holder = %q %q %s", tt.name, doc.Surname, doc.GivenNames, doc.Sex)
+		}
+		if doc.DocumentNumber != tt.number || doc.OptionalData != tt.optional {
+			t.Errorf("%s: number = %q, optional data = %q", tt.name, doc.DocumentNumber, doc.OptionalData)
+		}
+		if got := doc.BirthDate.Format("2006-01-02"); got != tt.birth {
+			t.Errorf("%s: birth date = %s", tt.name, got)
+		}
+		if got := doc.ExpiryDate.Format("2006-01-02"); got != tt.expiry {
+			t.Errorf("%s: expiry date = %s", tt.name, got)
+		}
+		if doc.Visa() {
+			t.Errorf("%s: reported as a visa", tt.name)
+		}
+	}
+}
+
+func TestParseLongDocumentNumber(t *testing.T) {
+	doc, err := Parse(td1LongNumber, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.DocumentNumber != "D23145890734" || doc.OptionalData != "" {
+		t.Errorf("number = %q, optional data = %q", doc.DocumentNumber, doc.OptionalData)
+	}
+	if doc.Surname != "STEVENSON" || doc.GivenNames != "PETER JOHN" || doc.Sex != "M" {
+		t.Errorf("holder = %q %q %s", doc.Surname, doc.GivenNames, doc.Sex)
+	}
+	if got := doc.BirthDate.Format("2006-01-02"); got != "1934-07-12" {
+		t.Errorf("birth date = %s", got)
+	}
+
+	// The last digit of the continued number is its check digit
+	broken := strings.Replace(td1LongNumber, "<7349<", "<7348<", 1)
+	_, err = Parse(broken, now)
+	var checkErr *CheckError
+	if !errors.As(err, &checkErr) || !slices.Contains(checkErr.Fields, "document_number") {
+		t.Errorf("wrong long-form check digit: %v", err)
+	}
+}
+
+func TestParseVisas(t *testing.T) {
+	for name, text := range map[string]string{"mrv-a": mrvASpecimen, "mrv-b": mrvBSpecimen} {
+		doc, err := Parse(text, now)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !doc.Visa() || doc.DocumentNumber != "L8988901C" || doc.Nationality != "XXX" {
+			t.Errorf("%s: document = %s %s %s", name, doc.DocumentCode, doc.DocumentNumber, doc.Nationality)
+		}
+		if doc.Surname != "ERIKSSON" || doc.GivenNames != "ANNA MARIA" {
+			t.Errorf("%s: holder = %q %q", name, doc.Surname, doc.GivenNames)
+		}
+		if got := doc.BirthDate.Format("2006-01-02"); got != "1940-09-07" {
+			t.Errorf("%s: birth date = %s", name, got)
+		}
+	}
+
+	doc, _ := Parse(mrvASpecimen, now)
+	if doc.Format != TD3 || doc.OptionalData != "6ZE184226B" {
+		t.Errorf("mrv-a: format %s, optional data %q", doc.Format, doc.OptionalData)
+	}
+	doc, _ = Parse(mrvBSpecimen, now)
+	if doc.Format != TD2 || doc.OptionalData != "" {
+		t.Errorf("mrv-b: format %s, optional data %q", doc.Format, doc.OptionalData)
+	}
+}
+
+func TestParseSingleLine(t *testing.T) {
+	// OCR often returns the zone as one line, in any case, with stray spaces
+	for name, text := range map[string]string{
+		"td1": strings.ReplaceAll(td1Specimen, "\n", ""),
+		"td2": strings.ReplaceAll(td2Specimen, "\n", ""),
+		"td3": strings.ToLower(strings.ReplaceAll(td3Specimen, "\n", " ")),
+	} {
+		doc, err := Parse(text, now)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if doc.DocumentNumber == "" || doc.Surname != "ERIKSSON" {
+			t.Errorf("%s: document = %+v", name, doc)
+		}
+	}
+
+	crlf := strings.ReplaceAll(td3Specimen, "\n", "\r\n") + "\r\n"
+	if _, err := Parse(crlf, now); err != nil {
+		t.Errorf("CRLF lines: %v", err)
+	}
+}
+
+func TestParseFlippedDigit(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		fields []string
+	}{
+		{"birth date", strings.Replace(td3Specimen, "7408122", "7408132", 1), []string{"birth_date", "composite"}},
+		{"document number", strings.Replace(td3Specimen, "L898902C36", "L898903C36", 1), []string{"document_number", "composite"}},
+		{"composite only", strings.Replace(td3Specimen, "<<<<<10", "<<<<<19", 1), []string{"composite"}},
+		{"td1 expiry", strings.Replace(td1Specimen, "1204159", "1204169", 1), []string{"expiry_date", "composite"}},
+		{"visa document number", strings.Replace(mrvASpecimen, "L8988901C4", "L8988901C5", 1), []string{"document_number"}},
+	}
+	for _, tt := range tests {
+		doc, err := Parse(tt.text, now)
+		var checkErr *CheckError
+		if !errors.As(err, &checkErr) {
+			t.Errorf("%s: err = %v, want CheckError", tt.name, err)
+			continue
+		}
+		if !slices.Equal(checkErr.Fields, tt.fields) {
+			t.Errorf("%s: failed fields = %v, want %v", tt.name, checkErr.Fields, tt.fields)
+		}
+		// The document still comes back so the agent can see what was read
+		if doc == nil || doc.Surname != "ERIKSSON" {
+			t.Errorf("%s: document = %+v", tt.name, doc)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"one short line": "P<UTOERIKSSON",
+		"uneven lines":   td3Specimen[:44] + "\n" + td3Specimen[45:80],
+		"bad character":  strings.Replace(td3Specimen, "ANNA", "AN-A", 1),
+		"wrong width":    strings.Replace(td2Specimen, "<\n", "\n", 1),
+		"bad date":       strings.Replace(td3Specimen, "7408122", "7413122", 1),
+	}
+	for name, text := range tests {
+		if _, err := Parse(text, now); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: err = %v, want ErrFormat", name, err)
+		}
+	}
+}
+
+func TestBirthCentury(t *testing.T) {
+	doc, err := Parse(td3Specimen, time.Date(2074, 8, 11, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.BirthDate.Year() != 1974 {
+		t.Errorf("birth year = %d", doc.BirthDate.Year())
+	}
+	doc, _ = Parse(td3Specimen, time.Date(2074, 8, 12, 0, 0, 0, 0, time.UTC))
+	if doc.BirthDate.Year() != 2074 {
+		t.Errorf("birth year on the day = %d", doc.BirthDate.Year())
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := map[string]byte{
+		"L898902C3": '6',
+		"740812":    '2',
+		"120415":    '9',
+		"ZE184226B": '1',
+		"<<<<<<<<<": '0',
+		"":          '0',
+	}
+	for value, want := range tests {
+		if got := CheckDigit(value); got != want {
+			t.Errorf("CheckDigit(%q) = %c, want %c", value, got, want)
+		}
+	}
+}
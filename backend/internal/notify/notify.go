@@ -0,0 +1,189 @@
+// Package notify sends email and SMS to customers through a transactional
+// outbox: callers enqueue messages inside their own database transaction and
+// a background sender delivers them with retries.
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Channels a message can be sent on
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Outbox states
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+const (
+	maxAttempts  = 6
+	baseBackoff  = time.Minute
+	pollInterval = 10 * time.Second
+	sendTimeout  = 30 * time.Second
+)
+
+// Message is a single notification to one recipient
+type Message struct {
+	Channel string
+	To      string
+	Subject string // email only
+	Body    string
+
+	Attachments []models.OutboxAttachment // email only
+}
+
+// Notifier delivers messages for one channel
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	notifiers = map[string]Notifier{}
+
+	wake = make(chan struct{}, 1)
+)
+
+// Setup picks a provider per channel from config, falling back to the
+// log-only provider for development
+func Setup() {
+	notifiers[ChannelEmail] = LogNotifier{}
+	if config.SMTP_HOST != "" {
+		notifiers[ChannelEmail] = SMTPNotifier{
+			Host:     config.SMTP_HOST,
+			Port:     config.SMTP_PORT,
+			Username: config.SMTP_USERNAME,
+			Password: config.SMTP_PASSWORD,
+			From:     config.SMTP_FROM,
+		}
+	}
+
+	notifiers[ChannelSMS] = LogNotifier{}
+	if config.SMS_API_URL != "" {
+		notifiers[ChannelSMS] = HTTPSMSNotifier{
+			URL:    config.SMS_API_URL,
+			APIKey: config.SMS_API_KEY,
+			From:   config.SMS_FROM,
+		}
+	}
+}
+
+// Enqueue writes the message to the outbox using tx, so it is only sent if
+// the surrounding transaction commits. Messages without a recipient are
+// skipped.
+func Enqueue(tx *gorm.DB, msg Message, reference string) error {
+	if msg.To == "" {
+		return nil
+	}
+
+	return tx.Create(&models.OutboxMessage{
+		Channel:       msg.Channel,
+		Recipient:     msg.To,
+		Subject:       msg.Subject,
+		Body:          msg.Body,
+		Attachments:   msg.Attachments,
+		Reference:     reference,
+		Status:        StatusPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// Wake asks the sender to look at the outbox now instead of at the next poll.
+// Call it after the enqueuing transaction has committed.
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartSender runs the background outbox sender until the process exits
+func StartSender() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			sendDue()
+			select {
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+}
+
+func sendDue() {
+	var due []models.OutboxMessage
+	if err := database.DB.
+		Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).
+		Order("id").
+		Limit(50).
+		Find(&due).Error; err != nil {
+		log.Printf("Failed to load outbox: %v", err)
+		return
+	}
+
+	for _, outbox := range due {
+		deliver(outbox)
+	}
+}
+
+// deliver sends one outbox row and records the result, backing off
+// exponentially between failed attempts
+func deliver(outbox models.OutboxMessage) {
+	err := send(outbox)
+
+	outbox.Attempts++
+	if err == nil {
+		now := time.Now()
+		outbox.Status = StatusSent
+		outbox.SentAt = &now
+		outbox.LastError = ""
+	} else {
+		log.Printf("Failed to send %s #%d: %v", outbox.Channel, outbox.ID, err)
+		outbox.LastError = err.Error()
+		if outbox.Attempts >= maxAttempts {
+			outbox.Status = StatusFailed
+		} else {
+			outbox.NextAttemptAt = time.Now().Add(baseBackoff << (outbox.Attempts - 1))
+		}
+	}
+
+	if err := database.DB.Model(&outbox).
+		Select("attempts", "status", "sent_at", "last_error", "next_attempt_at").
+		Updates(&outbox).Error; err != nil {
+		log.Printf("Failed to record outbox message %d: %v", outbox.ID, err)
+	}
+}
+
+func send(outbox models.OutboxMessage) error {
+	notifier, ok := notifiers[outbox.Channel]
+	if !ok {
+		notifier = LogNotifier{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	return notifier.Send(ctx, Message{
+		Channel: outbox.Channel,
+		To:      outbox.Recipient,
+		Subject: outbox.Subject,
+		Body:    outbox.Body,
+
+		Attachments: outbox.Attachments,
+	})
+}
@@ -0,0 +1,25 @@
+// Package pii formats customer personal data for display and normalizes it
+// for matching. Anything shown by default is masked; the full value is only
+// returned by an audited reveal.
+package pii
+
+import "strings"
+
+// MaskNationalID hides all but the last few characters, e.g. "*****6789".
+// Short IDs keep fewer characters so most of the value stays hidden.
+func MaskNationalID(id string) string {
+	runes := []rune(strings.TrimSpace(id))
+	if len(runes) == 0 {
+		return ""
+	}
+
+	visible := 4
+	switch {
+	case len(runes) <= 4:
+		visible = 0
+	case len(runes) < 8:
+		visible = 2
+	}
+
+	return strings.Repeat("*", len(runes)-visible) + string(runes[len(runes)-visible:])
+}
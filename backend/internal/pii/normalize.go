@@ -0,0 +1,40 @@
+package pii
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NormalizeEmail lower-cases and trims an email address. Local parts are
+// case-sensitive in theory but never in practice.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone keeps only the digits of a phone number and drops an
+// international "00" prefix, so "+977 984-1234567" and "009779841234567"
+// compare equal. Numbers written without a country code stay as they are.
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(strings.TrimSpace(phone), "00") {
+		digits = strings.TrimPrefix(digits, "00")
+	}
+	return digits
+}
+
+// NormalizeName lower-cases a name, drops punctuation and sorts its words, so
+// "Sharma, Ram" and "ram sharma" compare equal
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
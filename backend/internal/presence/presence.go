@@ -0,0 +1,66 @@
+// Package presence tracks agent availability. The status is stored on the
+// user row and cached in memory because routing checks it on every event.
+package presence
+
+import (
+	"sync"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+)
+
+var (
+	statuses = make(map[uint]string)
+	mutex    = &sync.RWMutex{}
+)
+
+// Valid reports whether status is one of the known agent states
+func Valid(status string) bool {
+	switch status {
+	case models.AgentAvailable, models.AgentBusy, models.AgentAway, models.AgentWrapUp:
+		return true
+	}
+	return false
+}
+
+// Set stores the agent's status
+func Set(userID uint, status string) error {
+	if err := database.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("status", status).Error; err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	statuses[userID] = status
+	mutex.Unlock()
+	return nil
+}
+
+// Get returns the agent's status, loading it from the database on first use
+func Get(userID uint) string {
+	mutex.RLock()
+	status, ok := statuses[userID]
+	mutex.RUnlock()
+	if ok {
+		return status
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "status").First(&user, userID).Error; err != nil {
+		return models.AgentAway
+	}
+	if user.Status == "" {
+		user.Status = models.AgentAvailable
+	}
+
+	mutex.Lock()
+	statuses[userID] = user.Status
+	mutex.Unlock()
+	return user.Status
+}
+
+// IsAvailable reports whether new customers may be routed to the agent
+func IsAvailable(userID uint) bool {
+	return Get(userID) == models.AgentAvailable
+}
@@ -0,0 +1,45 @@
+package reminders
+
+import (
+	"log"
+	"time"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+)
+
+// escalateDue marks unclaimed meetings past their escalate_at as escalated
+// and calls escalate for each, with the customer loaded. The conditional
+// update makes sure each one is announced only once.
+func escalateDue(escalate func(models.KYCSession)) {
+	if escalate == nil {
+		return
+	}
+
+	var due []models.KYCSession
+	if err := database.DB.
+		Where("escalate_at <= ? AND escalated = ? AND status = ? AND agent_id IS NULL",
+			time.Now(), false, models.SessionScheduled).
+		Preload("Customer").
+		Order("escalate_at").
+		Limit(100).
+		Find(&due).Error; err != nil {
+		log.Printf("Failed to load due escalations: %v", err)
+		return
+	}
+
+	for _, session := range due {
+		result := database.DB.Model(&models.KYCSession{}).
+			Where("id = ? AND escalated = ?", session.ID, false).
+			Update("escalated", true)
+		if result.Error != nil {
+			log.Printf("Failed to escalate meeting %s: %v", session.MeetingID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		session.Escalated = true
+		escalate(session)
+	}
+}
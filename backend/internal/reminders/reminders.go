@@ -0,0 +1,188 @@
+// Package reminders sends customers notifications ahead of their meeting and
+// opens waiting customers to every agent once their skills-based routing
+// window has passed. Both deadlines live in the database and are picked up
+// by a background scheduler, so nothing is lost across restarts.
+package reminders
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/jointoken"
+	"kyc-backend/internal/links"
+	"kyc-backend/internal/models"
+	"kyc-backend/internal/notify"
+
+	"gorm.io/gorm"
+)
+
+// Reminder states
+const (
+	StatusPending   = "pending"
+	StatusSent      = "sent"
+	StatusCancelled = "cancelled"
+)
+
+const pollInterval = 30 * time.Second
+
+// Schedule writes one reminder per configured lead time that is still in the
+// future. Call it inside the transaction that books the session.
+func Schedule(tx *gorm.DB, session models.KYCSession) error {
+	for _, lead := range config.REMINDER_OFFSETS {
+		dueAt := session.ScheduledAt.Add(-lead)
+		if !dueAt.After(time.Now()) {
+			continue
+		}
+
+		if err := tx.Create(&models.Reminder{
+			SessionID: session.ID,
+			Lead:      lead,
+			DueAt:     dueAt,
+			Status:    StatusPending,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel drops the session's pending reminders, e.g. when it is rescheduled,
+// cancelled or completed
+func Cancel(tx *gorm.DB, sessionID uint) error {
+	return tx.Model(&models.Reminder{}).
+		Where("session_id = ? AND status = ?", sessionID, StatusPending).
+		Update("status", StatusCancelled).Error
+}
+
+// Start runs the reminder and escalation scheduler until the process exits.
+// escalate announces each meeting the scheduler escalates; without it,
+// meetings are left unescalated.
+func Start(escalate func(models.KYCSession)) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			fireDue()
+			escalateDue(escalate)
+			<-ticker.C
+		}
+	}()
+}
+
+func fireDue() {
+	var due []models.Reminder
+	if err := database.DB.
+		Where("status = ? AND due_at <= ?", StatusPending, time.Now()).
+		Order("due_at").
+		Limit(100).
+		Find(&due).Error; err != nil {
+		log.Printf("Failed to load due reminders: %v", err)
+		return
+	}
+
+	sent := false
+	for _, reminder := range due {
+		if err := fire(reminder); err != nil {
+			log.Printf("Failed to send reminder %d: %v", reminder.ID, err)
+			continue
+		}
+		sent = true
+	}
+
+	if sent {
+		notify.Wake()
+	}
+}
+
+// fire hands the reminder to the notification outbox and marks it sent in
+// one transaction. Reminders for sessions that are no longer scheduled, or
+// whose meeting has already started, are cancelled instead. The session is
+// read again and the reminder only claimed while still pending, so one
+// cancelled by a concurrent reschedule or cancellation is never sent.
+func fire(reminder models.Reminder) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.KYCSession
+		if err := tx.Preload("Customer").First(&session, reminder.SessionID).Error; err != nil {
+			return err
+		}
+
+		status := StatusSent
+		if session.Status != models.SessionScheduled || !session.ScheduledAt.After(time.Now()) {
+			status = StatusCancelled
+		}
+		result := tx.Model(&models.Reminder{}).
+			Where("id = ? AND status = ?", reminder.ID, StatusPending).
+			Update("status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 || status != StatusSent {
+			return nil
+		}
+
+		for _, msg := range messages(session) {
+			if err := notify.Enqueue(tx, msg, session.MeetingID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func messages(session models.KYCSession) []notify.Message {
+	customer := session.Customer
+	link := links.MeetingLink(session.MeetingID, jointoken.Issue(session))
+	when := session.ScheduledAt.In(customer.Location()).Format("Mon, 02 Jan 2006 15:04 MST")
+	// A reminder can fire late, so say how long is really left
+	in := humanize(time.Until(session.ScheduledAt))
+
+	return []notify.Message{
+		{
+			Channel: notify.ChannelEmail,
+			To:      customer.Email,
+			Subject: "Reminder: your KYC video verification starts in " + in,
+			Body: fmt.Sprintf(
+				"Hello %s,\n\nThis is a reminder that your identity verification call starts in %s, at %s.\n\nJoin using this link:\n%s\n",
+				customer.FullName, in, when, link,
+			),
+		},
+		{
+			Channel: notify.ChannelSMS,
+			To:      customer.Phone,
+			Body:    fmt.Sprintf("Reminder: your KYC video call starts in %s (%s). Join: %s", in, when, link),
+		},
+	}
+}
+
+// humanize renders the time until a meeting as readable text, such as
+// "24 hours", "1 hour 20 minutes" or "1 minute". From 3 hours on it is
+// rounded to the hour.
+func humanize(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		d = time.Minute
+	}
+	if d >= 3*time.Hour {
+		d = d.Round(time.Hour)
+	}
+
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return plural(minutes, "minute")
+	case minutes == 0:
+		return plural(hours, "hour")
+	}
+	return plural(hours, "hour") + " " + plural(minutes, "minute")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
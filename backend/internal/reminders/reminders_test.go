@@ -0,0 +1,29 @@
+package reminders
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{24 * time.Hour, "24 hours"},
+		{23*time.Hour + 59*time.Minute + 30*time.Second, "24 hours"}, // fired a little late
+		{3*time.Hour + 29*time.Minute, "3 hours"},
+		{2*time.Hour + 15*time.Minute, "2 hours 15 minutes"},
+		{time.Hour, "1 hour"},
+		{time.Hour + time.Minute, "1 hour 1 minute"},
+		{15 * time.Minute, "15 minutes"},
+		{14*time.Minute + 40*time.Second, "15 minutes"},
+		{time.Minute, "1 minute"},
+		{20 * time.Second, "1 minute"},
+	}
+	for _, tt := range tests {
+		if got := humanize(tt.d); got != tt.want {
+			t.Errorf("humanize(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
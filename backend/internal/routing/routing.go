@@ -0,0 +1,53 @@
+// Package routing matches waiting customers to agents by skill.
+//
+// Skills are plain "kind:value" strings such as "lang:ne",
+// "nationality:np" or "product:savings".
+package routing
+
+import (
+	"strings"
+
+	"kyc-backend/internal/models"
+)
+
+// RequiredSkills derives the skills an agent needs to serve the customer
+func RequiredSkills(customer models.Customer) []string {
+	var skills []string
+	if customer.PreferredLanguage != "" {
+		skills = append(skills, "lang:"+normalize(customer.PreferredLanguage))
+	}
+	if customer.Nationality != "" {
+		skills = append(skills, "nationality:"+normalize(customer.Nationality))
+	}
+	if customer.Product != "" {
+		skills = append(skills, "product:"+normalize(customer.Product))
+	}
+	return skills
+}
+
+// Matches reports whether the agent has every required skill
+func Matches(agentSkills, required []string) bool {
+	have := make(map[string]bool, len(agentSkills))
+	for _, s := range agentSkills {
+		have[Normalize(s)] = true
+	}
+	for _, s := range required {
+		if !have[Normalize(s)] {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize lower-cases a skill and trims whitespace around its parts
+func Normalize(skill string) string {
+	kind, value, found := strings.Cut(skill, ":")
+	if !found {
+		return normalize(skill)
+	}
+	return normalize(kind) + ":" + normalize(value)
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,270 @@
+// Package slots turns agent working hours into bookable meeting slots. A
+// slot's capacity is the number of agents working for the whole slot, and
+// bookings are counted per slot so a full slot cannot be booked again.
+//
+// Every branch has its own calendar: slots follow the branch's local day,
+// its business hours and its public holidays, and only the branch's agents
+// count towards capacity. Branch 0 stands for agents without a branch, on
+// UTC, with no business hours or holidays.
+package slots
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"kyc-backend/config"
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPast          = errors.New("slot is in the past")
+	ErrBeyondHorizon = errors.New("slot is beyond the booking horizon")
+	ErrNotSlotStart  = errors.New("time is not the start of a slot")
+	ErrHoliday       = errors.New("branch is closed for a holiday")
+	ErrOutsideHours  = errors.New("slot is outside business hours")
+	ErrClosed        = errors.New("no agents work during this slot")
+	ErrFull          = errors.New("slot is fully booked")
+	ErrNoBranch      = errors.New("branch not found")
+)
+
+// HolidayError is ErrHoliday with the day and name of the holiday
+type HolidayError struct {
+	Date string // 2006-01-02, branch local
+	Name string
+}
+
+func (e *HolidayError) Error() string {
+	return fmt.Sprintf("branch is closed on %s for %s", e.Date, e.Name)
+}
+
+func (e *HolidayError) Is(target error) bool { return target == ErrHoliday }
+
+// Slot is one bookable period and how much room is left in it
+type Slot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Capacity  int       `json:"capacity"`
+	Booked    int       `json:"booked"`
+	Available int       `json:"available"`
+}
+
+// calendar is everything needed to work out one branch's slots
+type calendar struct {
+	loc      *time.Location
+	business []models.BusinessHours
+	holidays map[string]string // local date -> name
+	hours    []models.WorkingHours
+}
+
+func load(db *gorm.DB, branchID uint) (*calendar, error) {
+	cal := &calendar{loc: time.UTC, holidays: map[string]string{}}
+
+	agents := db.Model(&models.User{}).Select("id")
+	if branchID == 0 {
+		agents = agents.Where("branch_id IS NULL")
+	} else {
+		var branch models.Branch
+		if err := db.First(&branch, branchID).Error; err != nil {
+			return nil, ErrNoBranch
+		}
+		loc, err := time.LoadLocation(branch.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		cal.loc = loc
+		agents = agents.Where("branch_id = ?", branchID)
+
+		if err := db.Where("branch_id = ?", branchID).Find(&cal.business).Error; err != nil {
+			return nil, err
+		}
+		var holidays []models.Holiday
+		if err := db.Where("branch_id = ?", branchID).Find(&holidays).Error; err != nil {
+			return nil, err
+		}
+		for _, h := range holidays {
+			cal.holidays[h.Date] = h.Name
+		}
+	}
+
+	if err := db.Where("user_id IN (?)", agents).Find(&cal.hours).Error; err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// Validate checks that t is the start of a slot of the branch that can still
+// be booked and returns it in UTC. It does not check capacity, which Reserve
+// does.
+func Validate(branchID uint, t time.Time) (time.Time, error) {
+	t = t.UTC()
+	now := time.Now()
+
+	if !t.After(now) {
+		return t, ErrPast
+	}
+	if t.After(now.Add(config.BOOKING_HORIZON)) {
+		return t, ErrBeyondHorizon
+	}
+
+	cal, err := load(database.DB, branchID)
+	if err != nil {
+		return t, err
+	}
+	local := t.In(cal.loc)
+	if local.Second() != 0 || local.Nanosecond() != 0 || minuteOfDay(local)%slotMinutes() != 0 {
+		return t, ErrNotSlotStart
+	}
+	return t, cal.open(local)
+}
+
+// List returns the slots of the branch starting in [from, to) that have
+// capacity, limited to the future and the booking horizon
+func List(branchID uint, from, to time.Time) ([]Slot, error) {
+	now := time.Now()
+	if from.Before(now) {
+		from = now
+	}
+	if limit := now.Add(config.BOOKING_HORIZON); to.After(limit) {
+		to = limit
+	}
+
+	cal, err := load(database.DB, branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reservations []models.SlotReservation
+	if err := database.DB.
+		Where("branch_id = ? AND start >= ? AND start < ?", branchID, from.Unix(), to.Unix()).
+		Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	booked := make(map[int64]int, len(reservations))
+	for _, r := range reservations {
+		booked[r.Start] = r.Booked
+	}
+
+	slots := []Slot{}
+	for day := dayStart(from.In(cal.loc)); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for minute := 0; minute < 24*60; minute += slotMinutes() {
+			start := time.Date(day.Year(), day.Month(), day.Day(), 0, minute, 0, 0, cal.loc)
+			// Skip wall-clock times that do not exist on a DST change
+			if minuteOfDay(start) != minute {
+				continue
+			}
+			if start.Before(from) || !start.Before(to) || cal.open(start) != nil {
+				continue
+			}
+			capacity := capacityAt(cal.hours, start)
+			if capacity == 0 {
+				continue
+			}
+			taken := booked[start.Unix()]
+			slots = append(slots, Slot{
+				Start:     start.UTC(),
+				End:       start.Add(config.SLOT_LENGTH).UTC(),
+				Capacity:  capacity,
+				Booked:    taken,
+				Available: max(capacity-taken, 0),
+			})
+		}
+	}
+	return slots, nil
+}
+
+// Reserve books one place in the branch's slot starting at start. The
+// increment is conditional on the slot not being full, so concurrent bookings
+// cannot exceed its capacity. Call it inside the booking transaction.
+func Reserve(tx *gorm.DB, branchID uint, start time.Time) error {
+	cal, err := load(tx, branchID)
+	if err != nil {
+		return err
+	}
+	local := start.In(cal.loc)
+	if err := cal.open(local); err != nil {
+		return err
+	}
+	capacity := capacityAt(cal.hours, local)
+	if capacity == 0 {
+		return ErrClosed
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.SlotReservation{BranchID: branchID, Start: start.Unix()}).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&models.SlotReservation{}).
+		Where("branch_id = ? AND start = ? AND booked < ?", branchID, start.Unix(), capacity).
+		Update("booked", gorm.Expr("booked + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrFull
+	}
+	return nil
+}
+
+// Release gives back the place a cancelled or moved meeting held
+func Release(tx *gorm.DB, branchID uint, start time.Time) error {
+	return tx.Model(&models.SlotReservation{}).
+		Where("branch_id = ? AND start = ? AND booked > 0", branchID, start.Unix()).
+		Update("booked", gorm.Expr("booked - 1")).Error
+}
+
+// open checks the slot starting at local is on a working day and inside the
+// branch's business hours. A branch without business hours is open whenever
+// its agents work.
+func (cal *calendar) open(local time.Time) error {
+	date := local.Format("2006-01-02")
+	if name, ok := cal.holidays[date]; ok {
+		return &HolidayError{Date: date, Name: name}
+	}
+	if len(cal.business) == 0 {
+		return nil
+	}
+
+	weekday := int(local.Weekday())
+	from := minuteOfDay(local)
+	to := from + slotMinutes()
+	for _, h := range cal.business {
+		if h.Weekday == weekday && h.StartMinute <= from && h.EndMinute >= to {
+			return nil
+		}
+	}
+	return ErrOutsideHours
+}
+
+// capacityAt counts the distinct agents whose shift covers the whole slot.
+// start must be in the branch's time zone.
+func capacityAt(hours []models.WorkingHours, start time.Time) int {
+	weekday := int(start.Weekday())
+	from := minuteOfDay(start)
+	to := from + slotMinutes()
+
+	agents := make(map[uint]bool)
+	for _, h := range hours {
+		if h.Weekday == weekday && h.StartMinute <= from && h.EndMinute >= to {
+			agents[h.UserID] = true
+		}
+	}
+	return len(agents)
+}
+
+func slotMinutes() int {
+	return int(config.SLOT_LENGTH / time.Minute)
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,151 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Built-in countries. Deployments serving other countries Register their
+// own rules at startup.
+func init() {
+	Register(Country{Code: "NP", CallingCode: "977", TrunkPrefix: "0", PhoneLengths: []int{8, 10}})
+	Register(Country{Code: "IN", CallingCode: "91", TrunkPrefix: "0", PhoneLengths: []int{10}, NationalID: IDValidatorFunc(aadhaar)})
+	Register(Country{Code: "US", CallingCode: "1", TrunkPrefix: "1", PhoneLengths: []int{10}, NationalID: IDValidatorFunc(ssn)})
+	Register(Country{Code: "GB", CallingCode: "44", TrunkPrefix: "0", PhoneLengths: []int{9, 10}, NationalID: IDValidatorFunc(nino)})
+	Register(Country{Code: "SE", CallingCode: "46", TrunkPrefix: "0", PhoneLengths: []int{7, 8, 9}, NationalID: IDValidatorFunc(personnummer)})
+}
+
+// aadhaar checks an Indian Aadhaar number: 12 digits, not starting with 0
+// or 1, ending in a Verhoeff check digit
+func aadhaar(id string) (string, error) {
+	digits, ok := digitsOnly(id)
+	if !ok || len(digits) != 12 || digits[0] == '0' || digits[0] == '1' {
+		return "", ErrFormat
+	}
+	if !verhoeff(digits) {
+		return "", ErrChecksum
+	}
+	return digits, nil
+}
+
+// ssn checks a US Social Security number and writes it as AAA-GG-SSSS. SSNs
+// have no check digit, but some areas, groups and serials are never issued.
+func ssn(id string) (string, error) {
+	digits, ok := digitsOnly(id)
+	if !ok || len(digits) != 9 {
+		return "", ErrFormat
+	}
+	area, group, serial := digits[:3], digits[3:5], digits[5:]
+	if area == "000" || area == "666" || area[0] == '9' || group == "00" || serial == "0000" {
+		return "", ErrFormat
+	}
+	return fmt.Sprintf("%s-%s-%s", area, group, serial), nil
+}
+
+// nino checks a UK National Insurance number, e.g. "QQ 12 34 56 C"
+func nino(id string) (string, error) {
+	value := strings.ToUpper(strings.ReplaceAll(id, " ", ""))
+	if len(value) != 9 {
+		return "", ErrFormat
+	}
+
+	first, second := value[0], value[1]
+	if first < 'A' || first > 'Z' || strings.IndexByte("DFIQUV", first) >= 0 ||
+		second < 'A' || second > 'Z' || strings.IndexByte("DFIOQUV", second) >= 0 {
+		return "", ErrFormat
+	}
+	switch value[:2] {
+	case "BG", "GB", "KN", "NK", "NT", "TN", "ZZ":
+		return "", ErrFormat
+	}
+	for _, r := range value[2:8] {
+		if r < '0' || r > '9' {
+			return "", ErrFormat
+		}
+	}
+	if suffix := value[8]; suffix < 'A' || suffix > 'D' {
+		return "", ErrFormat
+	}
+	return value, nil
+}
+
+// personnummer checks a Swedish personal identity or coordination number and
+// writes it as YYMMDD-NNNC, where C is a Luhn check digit
+func personnummer(id string) (string, error) {
+	// "+" separates the date for people over 100; the digits are the same
+	digits, ok := digitsOnly(strings.ReplaceAll(id, "+", "-"))
+	if !ok {
+		return "", ErrFormat
+	}
+	if len(digits) == 12 {
+		digits = digits[2:]
+	}
+	if len(digits) != 10 {
+		return "", ErrFormat
+	}
+
+	month := int(digits[2]-'0')*10 + int(digits[3]-'0')
+	day := int(digits[4]-'0')*10 + int(digits[5]-'0')
+	if day > 60 {
+		day -= 60 // coordination numbers add 60 to the day
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return "", ErrFormat
+	}
+	if !luhn(digits) {
+		return "", ErrChecksum
+	}
+	return digits[:6] + "-" + digits[6:], nil
+}
+
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Verhoeff tables, see https://en.wikipedia.org/wiki/Verhoeff_algorithm
+var (
+	verhoeffD = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+func verhoeff(digits string) bool {
+	c := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		c = verhoeffD[c][verhoeffP[i%8][d]]
+	}
+	return c == 0
+}
@@ -0,0 +1,71 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+)
+
+// E.164 allows at most 15 digits after the "+"
+const (
+	minE164Digits = 8
+	maxE164Digits = 15
+)
+
+// NormalizePhone returns phone in E.164 form, e.g. "+9779841234567". Numbers
+// with a "+" or "00" prefix carry their own country code; others are read in
+// the numbering plan of region. Numbers for registered countries must have a
+// valid length; others are only checked against the E.164 limits.
+func NormalizePhone(phone, region string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", ErrRequired
+	}
+
+	international := strings.HasPrefix(phone, "+")
+	digits, ok := digitsOnly(strings.TrimPrefix(phone, "+"))
+	if !ok || digits == "" {
+		return "", ErrFormat
+	}
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	var country Country
+	var national string
+	if international {
+		var known bool
+		country, known = byCallingCode(digits)
+		if !known {
+			if len(digits) < minE164Digits || len(digits) > maxE164Digits {
+				return "", ErrFormat
+			}
+			return "+" + digits, nil
+		}
+		national = digits[len(country.CallingCode):]
+	} else {
+		if region == "" {
+			return "", ErrMissingCountryCode
+		}
+		var known bool
+		country, known = Lookup(region)
+		if !known {
+			return "", ErrUnsupportedCountry
+		}
+		national = digits
+	}
+
+	// "+44 (0)20 ..." and "020 ..." both drop the trunk prefix
+	if country.TrunkPrefix != "" {
+		national = strings.TrimPrefix(national, country.TrunkPrefix)
+	}
+	if len(country.PhoneLengths) > 0 && !slices.Contains(country.PhoneLengths, len(national)) {
+		return "", ErrFormat
+	}
+
+	e164 := country.CallingCode + national
+	if len(e164) > maxE164Digits {
+		return "", ErrFormat
+	}
+	return "+" + e164, nil
+}
@@ -0,0 +1,141 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	ErrRequired           = errors.New("value is required")
+	ErrFormat             = errors.New("value has the wrong format")
+	ErrChecksum           = errors.New("check digit does not match")
+	ErrUnsupportedCountry = errors.New("country is not supported")
+	ErrMissingCountryCode = errors.New("phone number needs a country code")
+	ErrTooYoung           = errors.New("below the minimum age")
+	ErrInFuture           = errors.New("date is in the future")
+)
+
+// IDValidator checks one country's national ID numbers
+type IDValidator interface {
+	// Normalize returns id in canonical form, or ErrFormat or ErrChecksum
+	Normalize(id string) (string, error)
+}
+
+// IDValidatorFunc adapts a function to IDValidator
+type IDValidatorFunc func(id string) (string, error)
+
+func (f IDValidatorFunc) Normalize(id string) (string, error) { return f(id) }
+
+// Country holds the validation rules of one country
+type Country struct {
+	Code        string // ISO 3166 alpha-2
+	CallingCode string // without "+", e.g. "977"
+	TrunkPrefix string // dialled before national numbers, usually "0"
+
+	// PhoneLengths lists the valid lengths of the national significant
+	// number, the part after the calling code
+	PhoneLengths []int
+
+	// NationalID validates the country's national ID. Without one any ID is
+	// accepted as long as it looks like an identifier.
+	NationalID IDValidator
+
+	// MinAge overrides MIN_CUSTOMER_AGE when set
+	MinAge int
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Country{}
+)
+
+// Register adds or replaces the rules of a country
+func Register(country Country) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[strings.ToUpper(country.Code)] = country
+}
+
+// Lookup returns the rules of a country, if registered
+func Lookup(code string) (Country, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	country, ok := registry[strings.ToUpper(code)]
+	return country, ok
+}
+
+// byCallingCode finds the registered country whose calling code starts
+// number, preferring the longest code
+func byCallingCode(number string) (Country, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	var best Country
+	for _, country := range registry {
+		if country.CallingCode != "" && strings.HasPrefix(number, country.CallingCode) &&
+			len(country.CallingCode) > len(best.CallingCode) {
+			best = country
+		}
+	}
+	return best, best.Code != ""
+}
+
+// NationalID validates and normalizes id under the rules of country. Countries
+// without a registered validator only get a sanity check.
+func NationalID(country, id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "", ErrRequired
+	}
+
+	if rules, ok := Lookup(country); ok && rules.NationalID != nil {
+		return rules.NationalID.Normalize(id)
+	}
+
+	if len(id) > 32 {
+		return "", ErrFormat
+	}
+	for _, r := range id {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -/.", r) {
+			return "", ErrFormat
+		}
+	}
+	return id, nil
+}
+
+// codeFor maps a validation error to its field error code
+func codeFor(err error) string {
+	switch {
+	case errors.Is(err, ErrRequired):
+		return CodeRequired
+	case errors.Is(err, ErrChecksum):
+		return CodeInvalidChecksum
+	case errors.Is(err, ErrUnsupportedCountry):
+		return CodeUnsupportedCountry
+	case errors.Is(err, ErrMissingCountryCode):
+		return CodeMissingCountryCode
+	case errors.Is(err, ErrTooYoung):
+		return CodeTooYoung
+	case errors.Is(err, ErrInFuture):
+		return CodeInFuture
+	}
+	return CodeInvalidFormat
+}
+
+// digitsOnly drops spaces and the separators people type into numbers, and
+// reports whether anything else was left
+func digitsOnly(value string) (string, bool) {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '/' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
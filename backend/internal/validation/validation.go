@@ -0,0 +1,144 @@
+// Package validation checks and normalizes the personal details customers
+// submit. Rules that differ by country, such as phone numbering plans and
+// national ID formats, come from a registry of Country entries; see
+// countries.go for the built-in ones and Register to add more.
+//
+// Problems are reported per field as stable codes, so the frontend can show
+// its own message next to the right input.
+package validation
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+
+	"kyc-backend/config"
+)
+
+// Field error codes
+const (
+	CodeRequired           = "required"
+	CodeInvalidFormat      = "invalid_format"
+	CodeInvalidChecksum    = "invalid_checksum"
+	CodeUnsupportedCountry = "unsupported_country"
+	CodeMissingCountryCode = "missing_country_code"
+	CodeTooYoung           = "too_young"
+	CodeInFuture           = "in_future"
+)
+
+// Fields of the profile request, as named in its JSON
+const (
+	FieldFullName    = "fullName"
+	FieldEmail       = "email"
+	FieldPhone       = "phone"
+	FieldDateOfBirth = "dateOfBirth"
+	FieldNationalID  = "nationalID"
+	FieldNationality = "nationality"
+)
+
+// Errors maps field names to error codes. Only the first problem with each
+// field is kept.
+type Errors map[string]string
+
+// Add records code for field unless the field already has an error
+func (e Errors) Add(field, code string) {
+	if _, ok := e[field]; !ok {
+		e[field] = code
+	}
+}
+
+// Profile is the customer-supplied part of a KYC profile. Validate rewrites
+// the fields in their canonical form.
+type Profile struct {
+	FullName    string
+	Email       string
+	Phone       string     // E.164 once validated
+	DateOfBirth *time.Time // optional
+	NationalID  string     // optional
+	Nationality string     // ISO 3166 alpha-2, optional
+}
+
+// Validate normalizes the profile and returns its field errors, empty when
+// the profile is valid. The nationality decides which national ID rules and
+// minimum age apply; phones without a country code are read in the
+// nationality's numbering plan, or DEFAULT_COUNTRY's.
+func (p *Profile) Validate(now time.Time) Errors {
+	errs := Errors{}
+
+	p.FullName = strings.Join(strings.Fields(p.FullName), " ")
+	if p.FullName == "" {
+		errs.Add(FieldFullName, CodeRequired)
+	}
+
+	p.Email = strings.TrimSpace(p.Email)
+	if p.Email == "" {
+		errs.Add(FieldEmail, CodeRequired)
+	} else if addr, err := mail.ParseAddress(p.Email); err != nil || addr.Address != p.Email {
+		errs.Add(FieldEmail, CodeInvalidFormat)
+	}
+
+	p.Nationality = strings.ToUpper(strings.TrimSpace(p.Nationality))
+	country, known := Lookup(p.Nationality)
+	if p.Nationality != "" && !isCountryCode(p.Nationality) {
+		errs.Add(FieldNationality, CodeInvalidFormat)
+	}
+
+	if p.Phone = strings.TrimSpace(p.Phone); p.Phone != "" {
+		region := p.Nationality
+		if !known {
+			region = config.DEFAULT_COUNTRY
+		}
+		phone, err := NormalizePhone(p.Phone, region)
+		if err != nil {
+			errs.Add(FieldPhone, codeFor(err))
+		} else {
+			p.Phone = phone
+		}
+	}
+
+	if p.NationalID = strings.TrimSpace(p.NationalID); p.NationalID != "" {
+		id, err := NationalID(p.Nationality, p.NationalID)
+		if err != nil {
+			errs.Add(FieldNationalID, codeFor(err))
+		} else {
+			p.NationalID = id
+		}
+	}
+
+	if p.DateOfBirth != nil {
+		minAge := config.MIN_CUSTOMER_AGE
+		if known && country.MinAge > 0 {
+			minAge = country.MinAge
+		}
+		if err := CheckAge(*p.DateOfBirth, now, minAge); err != nil {
+			errs.Add(FieldDateOfBirth, codeFor(err))
+		}
+	}
+
+	return errs
+}
+
+// CheckAge makes sure someone born on dob is at least minAge years old
+func CheckAge(dob, now time.Time, minAge int) error {
+	if dob.After(now) {
+		return ErrInFuture
+	}
+	// The birthday minAge years on; born on 29 February counts as 1 March
+	// in common years
+	if dob.AddDate(minAge, 0, 0).After(now) {
+		return ErrTooYoung
+	}
+	return nil
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNationalID(t *testing.T) {
+	tests := []struct {
+		country string
+		id      string
+		want    string
+		err     error
+	}{
+		// India: Aadhaar, Verhoeff check digit
+		{"IN", "2341 2341 2346", "234123412346", nil},
+		{"IN", "4991-1866-5246", "499118665246", nil},
+		{"IN", "234123412347", "", ErrChecksum},
+		{"IN", "243123412346", "", ErrChecksum}, // swapped digits
+		{"IN", "134123412346", "", ErrFormat},   // starts with 1
+		{"IN", "23412341234", "", ErrFormat},
+		{"IN", "2341A2341234", "", ErrFormat},
+
+		// Sweden: personnummer, Luhn check digit
+		{"SE", "811218-9876", "811218-9876", nil},
+		{"SE", "19811218-9876", "811218-9876", nil},
+		{"SE", "8112189876", "811218-9876", nil},
+		{"SE", "121212+1212", "121212-1212", nil}, // over 100
+		{"SE", "701063-2391", "701063-2391", nil}, // coordination number
+		{"SE", "811218-9875", "", ErrChecksum},
+		{"SE", "811318-9876", "", ErrFormat}, // month 13
+		{"SE", "811200-9876", "", ErrFormat}, // day 0
+		{"SE", "81121898", "", ErrFormat},
+
+		// United Kingdom: National Insurance number
+		{"GB", "AB 12 34 56 C", "AB123456C", nil},
+		{"GB", "ce123456a", "CE123456A", nil},
+		{"GB", "QQ123456C", "", ErrFormat}, // Q is never a first letter
+		{"GB", "AO123456C", "", ErrFormat}, // O is never a second letter
+		{"GB", "GB123456C", "", ErrFormat}, // unallocated prefix
+		{"GB", "AB123456E", "", ErrFormat},
+		{"GB", "AB12345C", "", ErrFormat},
+
+		// United States: Social Security number
+		{"US", "123-45-6789", "123-45-6789", nil},
+		{"US", "123456789", "123-45-6789", nil},
+		{"US", "000-45-6789", "", ErrFormat},
+		{"US", "666-45-6789", "", ErrFormat},
+		{"US", "912-45-6789", "", ErrFormat},
+		{"US", "123-00-6789", "", ErrFormat},
+		{"US", "123-45-0000", "", ErrFormat},
+		{"US", "123-45-678", "", ErrFormat},
+
+		// Nepal has no validator, only the sanity check
+		{"NP", "12-34-56-78901", "12-34-56-78901", nil},
+		{"NP", "  0123/456 ", "0123/456", nil},
+		{"NP", "12345<script>", "", ErrFormat},
+		{"NP", "", "", ErrRequired},
+		{"", "X1234567", "X1234567", nil},
+		{"", "1234567890123456789012345678901234", "", ErrFormat},
+	}
+	for _, tt := range tests {
+		got, err := NationalID(tt.country, tt.id)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("NationalID(%s, %q) = %q, %v, want %q, %v", tt.country, tt.id, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone  string
+		region string
+		want   string
+		err    error
+	}{
+		{"9841234567", "NP", "+9779841234567", nil},
+		{"01-4412345", "NP", "+97714412345", nil}, // trunk prefix dropped
+		{"+977 984-1234567", "", "+9779841234567", nil},
+		{"00977 9841234567", "", "+9779841234567", nil},
+		{"00977 9841234567", "GB", "+9779841234567", nil}, // the prefix beats the region
+		{"020 7946 0018", "GB", "+442079460018", nil},
+		{"+44 (0)20 7946 0018", "", "+442079460018", nil},
+		{"0044 20 7946 0018", "", "+442079460018", nil},
+		{"1 (202) 555-0143", "US", "+12025550143", nil},
+		{"(202) 555-0143", "us", "+12025550143", nil},
+		{"+91 98765 43210", "", "+919876543210", nil},
+		{"08-123 456 78", "SE", "+46812345678", nil},
+		{"+86 138 0013 8000", "", "+8613800138000", nil}, // unregistered calling code
+		{"+86 12", "", "", ErrFormat},
+		{"+86 1380 0138 0001 234", "", "", ErrFormat},
+		{"984123456", "NP", "", ErrFormat},
+		{"+9779841234", "", "", ErrFormat},
+		{"98412345678", "IN", "", ErrFormat},
+		{"9841234567", "", "", ErrMissingCountryCode},
+		{"0612345678", "FR", "", ErrUnsupportedCountry},
+		{"  ", "NP", "", ErrRequired},
+		{"98412x4567", "NP", "", ErrFormat},
+		{"+", "", "", ErrFormat},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.phone, tt.region)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("NormalizePhone(%q, %s) = %q, %v, want %q, %v", tt.phone, tt.region, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCallingCodeLookup(t *testing.T) {
+	// A shorter calling code that prefixes Nepal's must not take its numbers
+	Register(Country{Code: "ZZ", CallingCode: "97", PhoneLengths: []int{9}})
+	defer Register(Country{Code: "ZZ"})
+
+	if country, _ := byCallingCode("9779841234567"); country.Code != "NP" {
+		t.Errorf("+977 resolved to %s", country.Code)
+	}
+	if country, _ := byCallingCode("97123456789"); country.Code != "ZZ" {
+		t.Errorf("+97 resolved to %s", country.Code)
+	}
+	if _, ok := byCallingCode("8613800138000"); ok {
+		t.Error("+86 resolved to a registered country")
+	}
+	if got, err := NormalizePhone("+97 123456789", ""); got != "+97123456789" || err != nil {
+		t.Errorf("NormalizePhone(+97) = %q, %v", got, err)
+	}
+}
+
+func TestCheckAge(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		dob, now string
+		minAge   int
+		err      error
+	}{
+		{"2006-03-01", "2024-03-01", 18, nil},
+		{"2006-03-01", "2024-02-29", 18, ErrTooYoung},
+		{"2006-03-02", "2024-03-01", 18, ErrTooYoung},
+		{"1990-06-15", "2024-03-01", 18, nil},
+		// Born on 29 February: the birthday is 1 March in common years
+		{"2004-02-29", "2022-02-28", 18, ErrTooYoung},
+		{"2004-02-29", "2022-03-01", 18, nil},
+		{"2000-02-29", "2024-02-28", 24, ErrTooYoung},
+		{"2000-02-29", "2024-02-29", 24, nil},
+		{"2024-03-02", "2024-03-01", 0, ErrInFuture},
+		{"2024-03-01", "2024-03-01", 0, nil},
+	}
+	for _, tt := range tests {
+		err := CheckAge(date(tt.dob), date(tt.now), tt.minAge)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("CheckAge(%s, %s, %d) = %v, want %v", tt.dob, tt.now, tt.minAge, err, tt.err)
+		}
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	dob := time.Date(1990, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	p := Profile{
+		FullName:    "  Anna   Eriksson ",
+		Email:       "anna@example.com",
+		Phone:       "08-123 456 78",
+		DateOfBirth: &dob,
+		NationalID:  "19811218-9876",
+		Nationality: "se",
+	}
+	if errs := p.Validate(now); len(errs) != 0 {
+		t.Fatalf("errors = %v", errs)
+	}
+	if p.FullName != "Anna Eriksson" || p.Phone != "+46812345678" || p.NationalID != "811218-9876" || p.Nationality != "SE" {
+		t.Errorf("profile = %+v", p)
+	}
+
+	young := now.AddDate(-10, 0, 0)
+	p = Profile{
+		Email:       "Anna <anna@example.com>",
+		Phone:       "+9779841234",
+		DateOfBirth: &young,
+		NationalID:  "234123412347",
+		Nationality: "IN",
+	}
+	want := Errors{
+		FieldFullName:    CodeRequired,
+		FieldEmail:       CodeInvalidFormat,
+		FieldPhone:       CodeInvalidFormat,
+		FieldDateOfBirth: CodeTooYoung,
+		FieldNationalID:  CodeInvalidChecksum,
+	}
+	errs := p.Validate(now)
+	if len(errs) != len(want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+	for field, code := range want {
+		if errs[field] != code {
+			t.Errorf("%s = %q, want %q", field, errs[field], code)
+		}
+	}
+}
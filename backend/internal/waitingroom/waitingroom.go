@@ -0,0 +1,134 @@
+// Package waitingroom keeps the in-memory queue of customers who have
+// arrived for their meeting and are waiting for an agent.
+package waitingroom
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is a customer waiting in a queue
+type Entry struct {
+	MeetingID   string
+	Queue       string
+	ScheduledAt time.Time
+	ArrivedAt   time.Time
+
+	// Skills an agent needs to take this customer; cleared on escalation
+	Skills    []string
+	Escalated bool
+}
+
+// Position describes where a waiting customer currently stands
+type Position struct {
+	MeetingID     string
+	Queue         string
+	Position      int // 1-based
+	QueueLength   int
+	EstimatedWait time.Duration
+}
+
+var (
+	entries = make(map[string]Entry)
+	mutex   = &sync.Mutex{}
+)
+
+// Join adds the meeting to its queue and returns the stored entry. Re-joining
+// keeps the original arrival time and escalation so a page refresh does not
+// send the customer to the back.
+func Join(entry Entry) Entry {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if existing, ok := entries[entry.MeetingID]; ok {
+		entry.ArrivedAt = existing.ArrivedAt
+		if existing.Escalated {
+			entry.Skills = nil
+			entry.Escalated = true
+		}
+	}
+	if entry.ArrivedAt.IsZero() {
+		entry.ArrivedAt = time.Now()
+	}
+	entries[entry.MeetingID] = entry
+	return entry
+}
+
+// Leave removes the meeting from the waiting room, reporting whether it was there
+func Leave(meetingID string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	_, ok := entries[meetingID]
+	delete(entries, meetingID)
+	return ok
+}
+
+// Escalate opens a waiting meeting to agents without the required skills.
+// It reports true only the first time, so the fallback is announced once.
+func Escalate(meetingID string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	entry, ok := entries[meetingID]
+	if !ok || entry.Escalated {
+		return false
+	}
+	entry.Skills = nil
+	entry.Escalated = true
+	entries[meetingID] = entry
+	return true
+}
+
+// Waiting returns the entries of a queue in service order. An empty queue
+// name returns every queue merged.
+func Waiting(queue string) []Entry {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return ordered(queue)
+}
+
+// Positions reports the position and estimated wait of every waiting
+// customer, given the average time an agent spends on one session.
+func Positions(avgSession time.Duration) []Position {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	byQueue := make(map[string][]Entry)
+	for _, e := range ordered("") {
+		byQueue[e.Queue] = append(byQueue[e.Queue], e)
+	}
+
+	var positions []Position
+	for queue, list := range byQueue {
+		for i, e := range list {
+			positions = append(positions, Position{
+				MeetingID:     e.MeetingID,
+				Queue:         queue,
+				Position:      i + 1,
+				QueueLength:   len(list),
+				EstimatedWait: time.Duration(i) * avgSession,
+			})
+		}
+	}
+	return positions
+}
+
+// ordered sorts by scheduled time, then arrival. Caller must hold mutex.
+func ordered(queue string) []Entry {
+	list := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if queue == "" || e.Queue == queue {
+			list = append(list, e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].ScheduledAt.Equal(list[j].ScheduledAt) {
+			return list[i].ScheduledAt.Before(list[j].ScheduledAt)
+		}
+		return list[i].ArrivedAt.Before(list[j].ArrivedAt)
+	})
+	return list
+}
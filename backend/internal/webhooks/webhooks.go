@@ -0,0 +1,268 @@
+// Package webhooks delivers signed KYC lifecycle events to subscribed
+// endpoints. Deliveries are stored in the database first and sent by a
+// background dispatcher, so pending retries survive restarts.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kyc-backend/internal/database"
+	"kyc-backend/internal/models"
+)
+
+// Event types sent to subscribers
+const (
+	EventProfileSubmitted   = "profile.submitted"
+	EventMeetingScheduled   = "meeting.scheduled"
+	EventMeetingRescheduled = "meeting.rescheduled"
+	EventMeetingCancelled   = "meeting.cancelled"
+	EventSessionStarted     = "session.started"
+	EventSessionCompleted   = "session.completed"
+	EventSessionFailed      = "session.failed"
+	EventStatusChanged      = "customer.status_changed"
+	EventDuplicateSuspected = "customer.duplicate_suspected"
+	EventCustomerMerged     = "customer.merged"
+)
+
+// Delivery states
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+const (
+	maxAttempts  = 8
+	baseBackoff  = 30 * time.Second
+	pollInterval = 5 * time.Second
+)
+
+var (
+	client = &http.Client{Timeout: 10 * time.Second}
+
+	// wake lets Emit trigger a dispatch without waiting for the next poll
+	wake = make(chan struct{}, 1)
+)
+
+// Envelope is the JSON body posted to subscribers
+type Envelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Emit queues the event for every active subscription that wants it
+func Emit(eventType string, data interface{}) {
+	eventID, err := NewSecret()
+	if err != nil {
+		log.Printf("Failed to create webhook event id: %v", err)
+		return
+	}
+
+	payload, err := json.Marshal(Envelope{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Failed to encode %s webhook: %v", eventType, err)
+		return
+	}
+
+	var subs []models.WebhookSubscription
+	if err := database.DB.Where("active = ?", true).Find(&subs).Error; err != nil {
+		log.Printf("Failed to load webhook subscriptions: %v", err)
+		return
+	}
+
+	queued := false
+	for _, sub := range subs {
+		if !subscribed(sub, eventType) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         StatusPending,
+			NextAttemptAt:  time.Now(),
+		}
+		if err := database.DB.Create(&delivery).Error; err != nil {
+			log.Printf("Failed to queue %s webhook for subscription %d: %v", eventType, sub.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		nudge()
+	}
+}
+
+// Redeliver queues a fresh copy of an earlier delivery, keeping the original
+// in the log
+func Redeliver(deliveryID uint) (models.WebhookDelivery, error) {
+	var original models.WebhookDelivery
+	if err := database.DB.First(&original, deliveryID).Error; err != nil {
+		return original, err
+	}
+
+	delivery := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         StatusPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   &original.ID,
+	}
+	if err := database.DB.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+
+	nudge()
+	return delivery, nil
+}
+
+// StartDispatcher runs the background sender until the process exits
+func StartDispatcher() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			dispatchDue()
+			select {
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+}
+
+// NewSecret returns a random hex string, used for signing keys and event ids
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Sign computes the signature header value for a payload sent at the given
+// time. Receivers recompute HMAC-SHA256 over "<timestamp>.<body>" and compare.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func nudge() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func subscribed(sub models.WebhookSubscription, eventType string) bool {
+	if len(sub.Events) == 0 {
+		return true
+	}
+	for _, e := range sub.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchDue sends every pending delivery whose next attempt is due
+func dispatchDue() {
+	var due []models.WebhookDelivery
+	if err := database.DB.
+		Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).
+		Preload("Subscription").
+		Order("id").
+		Limit(50).
+		Find(&due).Error; err != nil {
+		log.Printf("Failed to load due webhooks: %v", err)
+		return
+	}
+
+	for _, delivery := range due {
+		attempt(delivery)
+	}
+}
+
+// attempt posts one delivery and records the outcome, scheduling a retry with
+// exponential backoff on failure
+func attempt(delivery models.WebhookDelivery) {
+	code, err := send(delivery)
+
+	delivery.Attempts++
+	delivery.ResponseCode = code
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = StatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= maxAttempts || !delivery.Subscription.Active {
+			delivery.Status = StatusFailed
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(baseBackoff << (delivery.Attempts - 1))
+		}
+	}
+
+	if err := database.DB.Model(&delivery).
+		Select("attempts", "response_code", "status", "delivered_at", "last_error", "next_attempt_at").
+		Updates(&delivery).Error; err != nil {
+		log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func send(delivery models.WebhookDelivery) (int, error) {
+	if !delivery.Subscription.Active {
+		return 0, fmt.Errorf("subscription %d is inactive", delivery.SubscriptionID)
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, delivery.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Signature", Sign(delivery.Subscription.Secret, time.Now().Unix(), body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
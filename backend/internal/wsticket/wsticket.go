@@ -0,0 +1,64 @@
+// Package wsticket issues short-lived, single-use tickets that authenticate
+// staff WebSocket connections. Browsers cannot set headers on a WebSocket,
+// and a login token in the URL ends up in proxy logs and browser history; a
+// ticket there is worthless once used or a few seconds old.
+package wsticket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TTL is how long a ticket can be redeemed after it is issued
+const TTL = 30 * time.Second
+
+type ticket struct {
+	userID    uint
+	expiresAt time.Time
+}
+
+var (
+	tickets = make(map[string]ticket)
+	mutex   = &sync.Mutex{}
+)
+
+// Issue returns a new ticket for the user
+func Issue(userID uint) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	value := hex.EncodeToString(b)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Drop expired tickets so unused ones do not pile up
+	now := time.Now()
+	for key, t := range tickets {
+		if now.After(t.expiresAt) {
+			delete(tickets, key)
+		}
+	}
+	tickets[value] = ticket{userID: userID, expiresAt: now.Add(TTL)}
+	return value, nil
+}
+
+// Redeem returns the user the ticket was issued to and invalidates it. It
+// reports false for unknown, used and expired tickets.
+func Redeem(value string) (uint, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	t, ok := tickets[value]
+	if !ok {
+		return 0, false
+	}
+	delete(tickets, value)
+	if time.Now().After(t.expiresAt) {
+		return 0, false
+	}
+	return t.userID, true
+}
@@ -0,0 +1,64 @@
+package wsticket
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRedeemOnce(t *testing.T) {
+	value, err := Issue(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID, ok := Redeem(value); !ok || userID != 7 {
+		t.Fatalf("Redeem = %d, %v", userID, ok)
+	}
+	if _, ok := Redeem(value); ok {
+		t.Error("ticket redeemed twice")
+	}
+	if _, ok := Redeem("not a ticket"); ok {
+		t.Error("unknown ticket redeemed")
+	}
+	if _, ok := Redeem(""); ok {
+		t.Error("empty ticket redeemed")
+	}
+}
+
+func TestRedeemExpired(t *testing.T) {
+	value, err := Issue(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutex.Lock()
+	tickets[value] = ticket{userID: 7, expiresAt: time.Now().Add(-time.Second)}
+	mutex.Unlock()
+
+	if _, ok := Redeem(value); ok {
+		t.Error("expired ticket redeemed")
+	}
+}
+
+func TestConcurrentRedeem(t *testing.T) {
+	value, err := Issue(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := Redeem(value); ok {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if wins.Load() != 1 {
+		t.Errorf("ticket redeemed %d times", wins.Load())
+	}
+}
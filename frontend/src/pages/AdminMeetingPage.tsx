@@ -25,11 +25,13 @@ export default function AdminMeetingPage() {
       }
 
       try {
-        const res = await api.get(`/kyc/meeting/${meetingId}`);
+        // The staff view is only open to the assigned agent, so claim first
+        await api.post(`/kyc/session/${meetingId}/claim`);
+        const res = await api.get(`/kyc/session/${meetingId}`);
         setCustomerInfo({
-          name: res.data.customer_name,
-          nationalID: res.data.national_id,
-          email: res.data.customer_email || "N/A",
+          name: res.data.customer.name,
+          nationalID: res.data.customer.national_id,
+          email: res.data.customer.email || "N/A",
         });
         setLoading(false);
       } catch (err: any) {
@@ -42,13 +44,30 @@ export default function AdminMeetingPage() {
   }, [meetingId]);
 
 
+  const handleRevealNationalID = async () => {
+    const reason = window.prompt("Why do you need the full National ID?");
+    if (!reason) return;
+
+    try {
+      const res = await api.post(`/kyc/session/${meetingId}/reveal`, {
+        field: "national_id",
+        reason,
+      });
+      setCustomerInfo((info) => info && { ...info, nationalID: res.data.value });
+    } catch (err: any) {
+      alert(err.response?.data?.error || "Failed to reveal National ID");
+    }
+  };
+
   const handleStartMeeting = async () => {
     try {
-      // 1. Tell backend to mark session as ongoing
+      // 1. Tell backend to mark session as ongoing (claimed on load)
       await api.post(`/kyc/session/${meetingId}/start`);
 
       // 2. Connect to WebSocket and signal customer
-      const wsUrl = import.meta.env.VITE_WS_URL ; //"ws://localhost:8080/ws"
+      // The socket authenticates with a single-use ticket, never the login token
+      const { data } = await api.post("/ws-ticket");
+      const wsUrl = `${import.meta.env.VITE_WS_URL}?ticket=${encodeURIComponent(data.ticket)}`; //"ws://localhost:8080/ws"
       const ws = new WebSocket(wsUrl);
 
       ws.onopen = () => {
@@ -116,9 +135,14 @@ export default function AdminMeetingPage() {
             </div>
             <div>
               <p className="text-sm text-muted-foreground">National ID</p>
-              <p className="font-mono bg-gray-100 p-2 rounded">
-                {customerInfo?.nationalID}
-              </p>
+              <div className="flex items-center gap-2">
+                <p className="font-mono bg-gray-100 p-2 rounded flex-1">
+                  {customerInfo?.nationalID}
+                </p>
+                <Button variant="outline" size="sm" onClick={handleRevealNationalID}>
+                  Reveal
+                </Button>
+              </div>
             </div>
             <div>
               <p className="text-sm text-muted-foreground">Email</p>
@@ -37,6 +37,13 @@ export default function DashboardPage() {
       }
     });
 
+    // Sent on reconnect when missed events have been pruned from the log
+    eventSource.addEventListener("reset", () => {
+      toast.warning("Some notifications were missed", {
+        description: "Check the waiting queue for customers.",
+      });
+    });
+
     eventSource.onerror = (err) => {
       console.error("SSE error", err);
     };
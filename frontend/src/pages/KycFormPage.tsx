@@ -19,6 +19,17 @@ import {
 import { kycFormSchema, type KycFormData } from '@/lib/schemas/kycSchema';
 import api  from '@/lib/api';
 
+// Messages for the field error codes the backend returns on submit
+const fieldErrorMessages: Record<string, string> = {
+  required: 'This field is required',
+  invalid_format: 'This does not look right',
+  invalid_checksum: 'This number is not valid, please check for typos',
+  unsupported_country: 'Please include your country code, e.g. +977',
+  missing_country_code: 'Please include your country code, e.g. +977',
+  too_young: 'You are below the minimum age for verification',
+  in_future: 'Date of birth cannot be in the future',
+};
+
 export default function KycFormPage() {
   const navigate = useNavigate();
   const [loading, setLoading] = useState(false);
@@ -29,6 +40,7 @@ export default function KycFormPage() {
     handleSubmit,
     setValue,
     watch,
+    setError: setFieldError,
     formState: { errors },
   } = useForm<KycFormData>({
     resolver: zodResolver(kycFormSchema),
@@ -51,6 +63,8 @@ export default function KycFormPage() {
       const formattedData = {
         ...data,
         dateOfBirth: format(data.dateOfBirth, 'yyyy-MM-dd'),
+        // Meeting times in emails and SMS are shown in this zone
+        timeZone: Intl.DateTimeFormat().resolvedOptions().timeZone,
       };
 
       const res = await api.post('/kyc/submit', formattedData);
@@ -60,6 +74,14 @@ export default function KycFormPage() {
       navigate(`/schedule?customerId=${customerId}`);
     } catch (err: any) {
       console.error(err);
+      const fields: Record<string, string> | undefined = err.response?.data?.fields;
+      if (fields) {
+        for (const [field, code] of Object.entries(fields)) {
+          setFieldError(field as keyof KycFormData, {
+            message: fieldErrorMessages[code] || 'This does not look right',
+          });
+        }
+      }
       setError(err.response?.data?.error || 'Failed to submit KYC form');
     } finally {
       setLoading(false);
@@ -116,6 +138,9 @@ export default function KycFormPage() {
                 placeholder="+1234567890"
                 {...register('phone')}
               />
+              {errors.phone && (
+                <p className="text-sm text-red-500">{errors.phone.message}</p>
+              )}
             </div>
 
             <div className="space-y-2">
@@ -1,15 +1,17 @@
 // pages/MeetingRoomPage.tsx
 import { useEffect, useState } from "react";
-import { useParams } from "react-router-dom";
+import { useParams, useSearchParams } from "react-router-dom";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
 import { Button } from "@/components/ui/button";
 import api from "@/lib/api"; // Make sure you're using named export
 
 export default function MeetingRoomPage() {
   const { meetingId } = useParams<{ meetingId: string }>();
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token") ?? "";
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
-  const [nationalID, setNationalID] = useState<string | null>(null);
+  const [firstName, setFirstName] = useState<string | null>(null);
   const [waitingForAdmin, setWaitingForAdmin] = useState(false);
   const [ws, setWs] = useState<WebSocket | null>(null);
 
@@ -22,12 +24,12 @@ export default function MeetingRoomPage() {
 
     const init = async () => {
       try {
-        // 1. Validate meeting and get National ID
-        const res = await api.get(`/kyc/meeting/${meetingId}`);
-        setNationalID(res.data.national_id);
+        // 1. Validate meeting with the link's token
+        const res = await api.get(`/kyc/meeting/${meetingId}`, { params: { token } });
+        setFirstName(res.data.first_name);
 
         // 2. Notify admin (triggers SSE)
-        await api.post(`/kyc/notify-admin`, { meeting_id: meetingId });
+        await api.post(`/kyc/notify-admin`, { meeting_id: meetingId, token });
         setWaitingForAdmin(true);
 
         // 3. Connect to WebSocket
@@ -42,6 +44,7 @@ export default function MeetingRoomPage() {
               room: meetingId,
               id: "customer-" + Date.now(),
               role: "customer",
+              token,
             })
           );
         };
@@ -51,7 +54,7 @@ export default function MeetingRoomPage() {
             const msg = JSON.parse(event.data);
             if (msg.event === "start_meeting") {
               // Redirect to shared call room
-              window.location.href = `/kyc-call/${meetingId}`;
+              window.location.href = `/kyc-call/${meetingId}?token=${encodeURIComponent(token)}`;
             }
           } catch (e) {
             console.error("WebSocket message parse error", e);
@@ -85,7 +88,7 @@ export default function MeetingRoomPage() {
         ws.close();
       }
     };
-  }, [meetingId]);
+  }, [meetingId, token]);
 
   if (loading) {
     return (
@@ -120,10 +123,7 @@ export default function MeetingRoomPage() {
           <CardTitle>KYC Verification</CardTitle>
         </CardHeader>
         <CardContent className="space-y-4">
-          <div>
-            <p className="text-sm text-muted-foreground">National ID</p>
-            <p className="font-mono bg-gray-100 p-2 rounded">{nationalID}</p>
-          </div>
+          {firstName && <p className="font-medium">Welcome, {firstName}</p>}
 
           {waitingForAdmin ? (
             <div className="text-center py-4">
@@ -1,15 +1,18 @@
 // src/pages/VideoCallPage.tsx
 import { useEffect, useRef, useState } from "react";
-import { useParams, useNavigate } from "react-router-dom";
+import { useParams, useNavigate, useSearchParams } from "react-router-dom";
 import { Button } from "@/components/ui/button";
 import { Card } from "@/components/ui/card";
 import { useAuthStore } from "@/stores/useAuthStore";
+import api from "@/lib/api";
 
 export default function VideoCallPage() {
   const { meetingId } = useParams<{ meetingId: string }>();
   const navigate = useNavigate();
   const user = useAuthStore((state) => state.user);
   const isCustomer = !user;
+  const [searchParams] = useSearchParams();
+  const joinToken = searchParams.get("token") ?? "";
 
   const [isMuted, setIsMuted] = useState(false);
   const [isCameraOff, setIsCameraOff] = useState(false);
@@ -89,8 +92,14 @@ export default function VideoCallPage() {
         };
 
         // Connect to WebSocket
-        const wsUrl = import.meta.env.VITE_WS_URL;
-        console.log("Connecting to WebSocket:", wsUrl);
+        // Staff authenticate the socket with a single-use ticket, never the
+        // login token itself
+        let wsUrl = import.meta.env.VITE_WS_URL;
+        if (!isCustomer) {
+          const { data } = await api.post("/ws-ticket");
+          wsUrl = `${wsUrl}?ticket=${encodeURIComponent(data.ticket)}`;
+        }
+        console.log("Connecting to WebSocket:", import.meta.env.VITE_WS_URL);
         const ws = new WebSocket(wsUrl);
         wsRef.current = ws;
 
@@ -109,6 +118,7 @@ export default function VideoCallPage() {
               room: meetingId,
               id: `${isCustomer ? "customer" : "agent"}-${Date.now()}`,
               role: isCustomer ? "customer" : "agent",
+              ...(isCustomer && { token: joinToken }),
             })
           );
 